@@ -71,11 +71,13 @@ func BuildApp(args BuildArgs) *cli.Command {
 		Commands: []*cli.Command{
 			buildDNSCleanerCommand(),
 			buildDNSPurgeCommand(),
+			buildDNSRestoreCommand(),
 			buildPruneDeploymentsCommand(),
 			buildPurgeDeploymentsCommand(),
 			buildGenerateDocsCommand(),
 			buildTunnelVersionCommand(),
 			buildListSyncCommand(),
+			buildListExportCommand(),
 			buildCacheCleanerCommand(),
 		},
 		Flags: []cli.Flag{
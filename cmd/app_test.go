@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -128,6 +132,10 @@ var (
 
 	// server is a test HTTP server used to provide mock API responses.
 	server *httptest.Server
+
+	// deletedDeployments are the IDs of the pages deployments deleted from the test server.
+	deletedDeployments   []string
+	deletedDeploymentsMu sync.Mutex
 )
 
 // setupTestHTTPServer sets up a test HTTP server with mock API responses.
@@ -137,6 +145,9 @@ func setupTestHTTPServer(t *testing.T) {
 	// test server
 	mux = http.NewServeMux()
 	server = httptest.NewServer(mux)
+	deletedDeploymentsMu.Lock()
+	deletedDeployments = nil
+	deletedDeploymentsMu.Unlock()
 
 	// disable rate limits and retries in testing - prepended so any provided value overrides this
 	t.Setenv("CLOUDFLARE_ACCOUNT_ID", "1")
@@ -144,6 +155,7 @@ func setupTestHTTPServer(t *testing.T) {
 	t.Setenv("CLOUDFLARE_BASE_URL", server.URL)
 	t.Setenv("LOG_LEVEL_TRACE", "true")
 	t.Setenv("CLOUDFLARE_ZONE_ID", "2")
+	t.Setenv("DNS_BACKUP_DIR", t.TempDir())
 	verifyHandler := func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, http.MethodGet, r.Method, "Expected a GET request")
 		w.Header().Set("content-type", "application/json")
@@ -222,6 +234,44 @@ func setupTestHTTPServer(t *testing.T) {
 			"errors": [],
 			"messages": [],
 			"result": [
+				{
+				"id": "4f2d1c3b-8a9e-4b7c-9d6e-5f4a3b2c1d0e",
+				"short_id": "4f2d1c3b",
+				"project_id": "80776025-b1bd-4181-993f-8238c27d226f",
+				"project_name": "test",
+				"environment": "preview",
+				"url": "https://4f2d1c3b.test.pages.dev",
+				"created_on": "2022-03-01T00:00:00Z",
+				"modified_on": "2022-03-01T00:00:00Z",
+				"deployment_trigger": {
+					"type": "github:push",
+					"metadata": {
+						"branch": "feature/login",
+						"commit_hash": "8d1f5c0e2b7a4c3d9e6f1a2b3c4d5e6f7a8b9c0d",
+						"commit_message": "Add login page"
+					}
+				},
+				"aliases": ["feature-login.test.pages.dev"]
+			},
+				{
+				"id": "7b1e9a2c-3d4f-4e5a-8b6c-9d0e1f2a3b4c",
+				"short_id": "7b1e9a2c",
+				"project_id": "80776025-b1bd-4181-993f-8238c27d226f",
+				"project_name": "test",
+				"environment": "preview",
+				"url": "https://7b1e9a2c.test.pages.dev",
+				"created_on": "2021-06-01T00:00:00Z",
+				"modified_on": "2021-06-01T00:00:00Z",
+				"deployment_trigger": {
+					"type": "github:push",
+					"metadata": {
+						"branch": "dependabot/npm_and_yarn/lodash-4.17.21",
+						"commit_hash": "3a9c7e5b1d2f4a6c8e0b2d4f6a8c0e2b4d6f8a0c",
+						"commit_message": "Bump lodash from 4.17.20 to 4.17.21"
+					}
+				},
+				"aliases": null
+			},
 				{
 				"id": "0012e50b-fa5d-44db-8cb5-1f372785dcbe",
 				"short_id": "0012e50b",
@@ -299,19 +349,56 @@ func setupTestHTTPServer(t *testing.T) {
 					}
 				},
 				"aliases": null
+			},
+				{
+				"id": "9c8d7e6f-5a4b-4c3d-8e1f-0a9b8c7d6e5f",
+				"short_id": "9c8d7e6f",
+				"project_id": "80776025-b1bd-4181-993f-8238c27d226f",
+				"project_name": "test",
+				"environment": "preview",
+				"url": "https://9c8d7e6f.test.pages.dev",
+				"created_on": "2005-06-01T00:00:00Z",
+				"modified_on": "2005-06-01T00:00:00Z",
+				"deployment_trigger": {
+					"type": "ad_hoc",
+					"metadata": {
+						"branch": "main",
+						"commit_hash": "5b2e8f1a9c3d4e7f6a0b1c2d3e4f5a6b7c8d9e0f",
+						"commit_message": "Old test commit"
+					}
+				},
+				"aliases": null
 			}
 					],
 			"result_info": {
 				"page": 1,
 				"per_page": 100,
-				"count": 1,
+				"count": 4,
 				"total_pages": 1
 			  }
 				}`)
 	}
 	dnsRecordsPage1Handler := func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, http.MethodGet, r.Method, "Expected a GET request")
 		w.Header().Set("content-type", "application/json")
+		if r.Method == http.MethodPost {
+			fmt.Fprint(w, `{
+			"success": true,
+			"errors": [],
+			"messages": [],
+			"result": {
+				"id": "023e105f4ecef8ad9ca31a8372d0c353",
+				"type": "A",
+				"name": "new.example.com",
+				"content": "198.51.100.5",
+				"proxied": false,
+				"ttl": 1,
+				"created_on": "2014-01-01T05:20:00Z",
+				"modified_on": "2014-01-01T05:20:00Z"
+			}
+		}`)
+			return
+		}
+		assert.Equal(t, http.MethodGet, r.Method, "Expected a GET request")
 		fmt.Fprint(w, `{
 			"success": true,
 			"errors": [],
@@ -375,9 +462,27 @@ func setupTestHTTPServer(t *testing.T) {
 		}`)
 		}
 	}
-	dnsRecordDeleteHandler := func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, http.MethodDelete, r.Method, "Expected a DELETE request")
+	dnsRecordHandler := func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("content-type", "application/json")
+		if r.Method == http.MethodPatch {
+			fmt.Fprint(w, `{
+			"success": true,
+			"errors": [],
+			"messages": [],
+			"result": {
+				"id": "372e67954025e0ba6aaa6d586b9e0b59",
+				"type": "A",
+				"name": "example.com",
+				"content": "198.51.100.5",
+				"proxied": false,
+				"ttl": 120,
+				"created_on": "2014-01-01T05:20:00Z",
+				"modified_on": "2014-01-01T05:20:00Z"
+			}
+		}`)
+			return
+		}
+		assert.Equal(t, http.MethodDelete, r.Method, "Expected a DELETE request")
 		fmt.Fprint(w, `{
 			"success": true,
 			"errors": [],
@@ -442,6 +547,9 @@ func setupTestHTTPServer(t *testing.T) {
 	}
 	deletePagesDeploymentHandler := func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, http.MethodDelete, r.Method, "Expected a DELETE request")
+		deletedDeploymentsMu.Lock()
+		deletedDeployments = append(deletedDeployments, path.Base(r.URL.Path))
+		deletedDeploymentsMu.Unlock()
 		w.Header().Set("content-type", "application/json")
 		fmt.Fprintf(w, `{
 			"success": true,
@@ -450,9 +558,31 @@ func setupTestHTTPServer(t *testing.T) {
 			"result": null
 		}`)
 	}
-	deletePagesProjectHandler := func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, http.MethodDelete, r.Method, "Expected a DELETE request")
+	pagesProjectHandler := func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("content-type", "application/json")
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `{
+			"success": true,
+			"errors": [],
+			"messages": [],
+			"result": {
+				"id": "80776025-b1bd-4181-993f-8238c27d226f",
+				"name": "cloudflare-utils-pages-project",
+				"subdomain": "cloudflare-utils-pages-project.pages.dev",
+				"production_branch": "main",
+				"canonical_deployment": {
+					"id": "0012e50b-fa5d-44db-8cb5-1f372785dcbe",
+					"environment": "production"
+				},
+				"latest_deployment": {
+					"id": "0012e50b-fa5d-44db-8cb5-1f372785dcbe",
+					"environment": "production"
+				}
+			}
+		}`)
+			return
+		}
+		assert.Equal(t, http.MethodDelete, r.Method, "Expected a DELETE request")
 		fmt.Fprintf(w, `{
 			"success": true,
 			"errors": [],
@@ -465,10 +595,26 @@ func setupTestHTTPServer(t *testing.T) {
 	mux.HandleFunc("/accounts/1/pages/projects/cloudflare-utils-pages-project/deployments", pagesDeploymentPage1Handler)
 	mux.HandleFunc("/zones/2/dns_records", dnsRecordsPage1Handler)
 	mux.HandleFunc("/zones/", zoneLookupHandler)
-	mux.HandleFunc("/zones/2/dns_records/372e67954025e0ba6aaa6d586b9e0b59", dnsRecordDeleteHandler)
+	mux.HandleFunc("/zones/2/dns_records/372e67954025e0ba6aaa6d586b9e0b59", dnsRecordHandler)
 	mux.HandleFunc("/accounts/1/cfd_tunnel", tunnelListHandler)
-	mux.HandleFunc("/accounts/1/pages/projects/cloudflare-utils-pages-project/deployments/0012e50b-fa5d-44db-8cb5-1f372785dcbe", deletePagesDeploymentHandler)
-	mux.HandleFunc("/accounts/1/pages/projects/cloudflare-utils-pages-project", deletePagesProjectHandler)
+	mux.HandleFunc("/accounts/1/pages/projects/cloudflare-utils-pages-project/deployments/", deletePagesDeploymentHandler)
+	mux.HandleFunc("/accounts/1/pages/projects/cloudflare-utils-pages-project", pagesProjectHandler)
+	mux.HandleFunc("/accounts/1/pages/projects/docs-site/deployments", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method, "Expected a GET request")
+		w.Header().Set("content-type", "application/json")
+		fmt.Fprint(w, `{
+			"success": true,
+			"errors": [],
+			"messages": [],
+			"result": [],
+			"result_info": {
+				"page": 1,
+				"per_page": 100,
+				"count": 0,
+				"total_pages": 1
+			}
+		}`)
+	})
 	mux.HandleFunc("/ips?china_colo=1", func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, http.MethodGet, r.Method, "Expected a GET request for /ips")
 		w.Header().Set("content-type", "application/json")
@@ -492,6 +638,73 @@ func setupTestHTTPServer(t *testing.T) {
 			}
 		}`)
 	})
+	mux.HandleFunc("/accounts/1/pages/projects", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method, "Expected method 'GET', got %s", r.Method)
+		w.Header().Set("content-type", "application/json")
+		fmt.Fprint(w, `{
+			"result": [
+				{
+					"id": "7b162ea7-7367-4d67-bcde-1160995d5",
+					"name": "cloudflare-utils-pages-project",
+					"subdomain": "cloudflare-utils-pages-project.pages.dev",
+					"domains": ["cloudflare-utils-pages-project.pages.dev"],
+					"production_branch": "main",
+					"created_on": "2017-01-01T00:00:00Z"
+				},
+				{
+					"id": "3c5e7a9b-1d2f-4a6c-8e0b-2d4f6a8c0e2b",
+					"name": "docs-site",
+					"subdomain": "docs-site.pages.dev",
+					"domains": ["docs-site.pages.dev"],
+					"production_branch": "main",
+					"created_on": "2019-01-01T00:00:00Z"
+				}
+			],
+			"result_info": {
+				"count": 2,
+				"page": 1,
+				"per_page": 10,
+				"total_count": 2,
+				"total_pages": 1
+			},
+			"success": true,
+			"errors": [],
+			"messages": []
+		}`)
+	})
+	mux.HandleFunc("/accounts/1/workers/scripts", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method, "Expected method 'GET', got %s", r.Method)
+		w.Header().Set("content-type", "application/json")
+		fmt.Fprint(w, `{
+			"result": [
+				{
+					"id": "cloudflare-utils-worker",
+					"etag": "ea95132c15732412d22c1476fa83f27a",
+					"created_on": "2017-01-01T00:00:00Z",
+					"modified_on": "2017-01-01T00:00:00Z"
+				}
+			],
+			"success": true,
+			"errors": [],
+			"messages": []
+		}`)
+	})
+	mux.HandleFunc("/zones/2/workers/routes", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method, "Expected method 'GET', got %s", r.Method)
+		w.Header().Set("content-type", "application/json")
+		fmt.Fprint(w, `{
+			"result": [
+				{
+					"id": "9a7806061c88ada191ed06f989cc3dac",
+					"pattern": "example.com/*",
+					"script": "cloudflare-utils-worker"
+				}
+			],
+			"success": true,
+			"errors": [],
+			"messages": []
+		}`)
+	})
 	mux.HandleFunc("/accounts/1/rules/lists", func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, http.MethodGet, r.Method, "Expected method 'GET', got %s", r.Method)
 		w.Header().Set("content-type", "application/json")
@@ -506,16 +719,93 @@ func setupTestHTTPServer(t *testing.T) {
 					"num_referencing_filters": 2,
 					"created_on": "2020-01-01T08:00:00Z",
 					"modified_on": "2020-01-10T14:00:00Z"
+				},
+				{
+					"id": "5e8cd1c2a1f94d8fb0b2d5c1f3a4e6b7",
+					"name": "test-redirects",
+					"description": "Bulk redirects",
+					"kind": "redirect",
+					"num_items": 1,
+					"num_referencing_filters": 1,
+					"created_on": "2020-01-01T08:00:00Z",
+					"modified_on": "2020-01-10T14:00:00Z"
+				}
+			],
+			"success": true,
+			"errors": [],
+			"messages": []
+		}`)
+	})
+	mux.HandleFunc("/accounts/1/rules/lists/5e8cd1c2a1f94d8fb0b2d5c1f3a4e6b7/items", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `{
+			"result": [
+				{
+					"id": "9a1b2c3d4e5f46a7b8c9d0e1f2a3b4c5",
+					"redirect": {
+						"source_url": "example.com/blog",
+						"target_url": "https://example.com/news",
+						"status_code": 301,
+						"include_subdomains": false,
+						"subpath_matching": false,
+						"preserve_query_string": false,
+						"preserve_path_suffix": false
+					},
+					"comment": "Old blog",
+					"created_on": "2020-01-01T08:00:00Z",
+					"modified_on": "2020-01-10T14:00:00Z"
 				}
 			],
+			"result_info": {
+				"cursors": {}
+			},
+			"success": true,
+			"errors": [],
+			"messages": []
+		}`)
+			return
+		}
+		assert.Contains(t, []string{http.MethodPut, http.MethodPost, http.MethodDelete}, r.Method, "Expected method 'PUT', 'POST' or 'DELETE', got %s", r.Method)
+		fmt.Fprint(w, `{
+			"result": {
+				"operation_id": "4da8780eeb215e6cb7f48dd981c4ea02"
+			},
 			"success": true,
 			"errors": [],
 			"messages": []
 		}`)
 	})
 	mux.HandleFunc("/accounts/1/rules/lists/2c0fc9fa937b11eaa1b71c4d701ab86e/items", func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, http.MethodPut, r.Method, "Expected method 'PUT', got %s", r.Method)
 		w.Header().Set("content-type", "application/json")
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `{
+			"result": [
+				{
+					"id": "2c0fc9fa937b11eaa1b71c4d701ab86e",
+					"ip": "1.2.3.4",
+					"comment": "Private IP address",
+					"created_on": "2020-01-01T08:00:00Z",
+					"modified_on": "2020-01-10T14:00:00Z"
+				},
+				{
+					"id": "7c5dae5552338874e5053f2534d2767a",
+					"ip": "198.51.100.0/24",
+					"comment": "Old range",
+					"created_on": "2020-01-01T08:00:00Z",
+					"modified_on": "2020-01-10T14:00:00Z"
+				}
+			],
+			"result_info": {
+				"cursors": {}
+			},
+			"success": true,
+			"errors": [],
+			"messages": []
+		}`)
+			return
+		}
+		assert.Contains(t, []string{http.MethodPut, http.MethodPost, http.MethodDelete}, r.Method, "Expected method 'PUT', 'POST' or 'DELETE', got %s", r.Method)
 		fmt.Fprint(w, `{
 			"result": {
 				"operation_id": "4da8780eeb215e6cb7f48dd981c4ea02"
@@ -540,6 +830,33 @@ func setupTestHTTPServer(t *testing.T) {
 			"messages": []
 		}`)
 	})
+	mux.HandleFunc("/accounts/1/rules/lists/bulk_operations/7c1e7d1f2b7a4e0fa6c54e2a3f9b8d10", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method, "Expected method 'GET', got %s", r.Method)
+		w.Header().Set("content-type", "application/json")
+		fmt.Fprint(w, `{
+			"result": {
+				"id": "7c1e7d1f2b7a4e0fa6c54e2a3f9b8d10",
+				"status": "running"
+			},
+			"success": true,
+			"errors": [],
+			"messages": []
+		}`)
+	})
+	mux.HandleFunc("/accounts/1/rules/lists/bulk_operations/0b6f2d8e9a3c4b5d8e7f6a5b4c3d2e1f", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method, "Expected method 'GET', got %s", r.Method)
+		w.Header().Set("content-type", "application/json")
+		fmt.Fprint(w, `{
+			"result": {
+				"id": "0b6f2d8e9a3c4b5d8e7f6a5b4c3d2e1f",
+				"status": "failed",
+				"error": "This list is at the maximum number of items"
+			},
+			"success": true,
+			"errors": [],
+			"messages": []
+		}`)
+	})
 	mux.HandleFunc("/zones/2/purge_cache", func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, http.MethodPost, r.Method, "Expected method 'POST', got %s", r.Method)
 		w.Header().Set("content-type", "application/json")
@@ -558,6 +875,41 @@ func teardownTestHTTPServer() {
 	server.Close()
 }
 
+// testDeletedDeployments returns the sorted IDs of the pages deployments deleted from the test server.
+func testDeletedDeployments() []string {
+	deletedDeploymentsMu.Lock()
+	defer deletedDeploymentsMu.Unlock()
+	deleted := slices.Clone(deletedDeployments)
+	slices.Sort(deleted)
+	return deleted
+}
+
+// captureStdout runs fn while capturing os.Stdout and returns what it printed.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	orig := os.Stdout
+	r, w, err := os.Pipe()
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = orig })
+
+	done := make(chan struct{})
+	var output []byte
+	go func() {
+		output, _ = io.ReadAll(r)
+		close(done)
+	}()
+
+	fn()
+
+	_ = w.Close()
+	<-done
+	os.Stdout = orig
+	return string(output)
+}
+
 func withApp(t *testing.T, args []string) error {
 	setupTestHTTPServer(t)
 	t.Cleanup(teardownTestHTTPServer)
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/sourcegraph/conc/pool"
+)
+
+// bulkOptions configure how a bulk operation is run.
+type bulkOptions struct {
+	// MaxGoroutines is the number of items worked on at once.
+	MaxGoroutines int
+}
+
+// defaultBulkOptions are the options used by the bulk delete helpers.
+var defaultBulkOptions = bulkOptions{
+	MaxGoroutines: maxGoRoutines,
+}
+
+// bulkResult is the outcome of a single item of a bulk operation.
+type bulkResult struct {
+	ID  string
+	Err error
+	// Canceled is true if the item was never run because the context was canceled.
+	Canceled bool
+}
+
+// bulkReport is the outcome of every item of a bulk operation, in the order the items were given.
+type bulkReport struct {
+	Results []bulkResult
+}
+
+// Succeeded is the number of items that completed without an error.
+func (r bulkReport) Succeeded() int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Err == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Failed are the items that were run and returned an error.
+func (r bulkReport) Failed() []bulkResult {
+	var failed []bulkResult
+	for _, result := range r.Results {
+		if result.Err != nil && !result.Canceled {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Canceled is the number of items that were not run because the context was canceled.
+func (r bulkReport) Canceled() int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Canceled {
+			count++
+		}
+	}
+	return count
+}
+
+// Errors returns the error of every item that did not succeed keyed by the item ID.
+func (r bulkReport) Errors() map[string]error {
+	errs := make(map[string]error)
+	for _, result := range r.Results {
+		if result.Err != nil {
+			errs[result.ID] = result.Err
+		}
+	}
+	return errs
+}
+
+// runBulk runs fn for every item using a pool of goroutines.
+// Rate limit and server errors are already retried by the retry policy of APIClient, so items are not retried here.
+// Once ctx is canceled no new items are started and the remaining items are reported as canceled.
+func runBulk[T any](ctx context.Context, items []T, id func(T) string, fn func(context.Context, T) error, options bulkOptions) bulkReport {
+	report := bulkReport{Results: make([]bulkResult, len(items))}
+	p := pool.New().WithMaxGoroutines(max(options.MaxGoroutines, 1))
+	for i, item := range items {
+		itemID := id(item)
+		if ctx.Err() != nil {
+			report.Results[i] = bulkResult{ID: itemID, Err: ctx.Err(), Canceled: true}
+			continue
+		}
+		p.Go(func() {
+			result := bulkResult{ID: itemID}
+			if ctx.Err() != nil {
+				result.Err = ctx.Err()
+				result.Canceled = true
+			} else {
+				result.Err = fn(ctx, item)
+			}
+			// Each goroutine only writes the result at its own index.
+			report.Results[i] = result
+		})
+	}
+	p.Wait()
+	return report
+}
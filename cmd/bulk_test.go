@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+var testBulkOptions = bulkOptions{MaxGoroutines: 4}
+
+func Test_RunBulk(t *testing.T) {
+	logger = logrus.New()
+	items := make([]int, 50)
+	for i := range items {
+		items[i] = i
+	}
+	var calls atomic.Int32
+	report := runBulk(t.Context(), items, strconv.Itoa, func(_ context.Context, item int) error {
+		calls.Add(1)
+		switch item {
+		case 7:
+			return &cloudflare.RatelimitError{}
+		case 9:
+			return errors.New("not found")
+		}
+		return nil
+	}, testBulkOptions)
+
+	assert.Len(t, report.Results, len(items), "Expected a result for every item")
+	assert.Equal(t, int32(len(items)), calls.Load(), "Expected every item to run once, because APIClient retries rate limits")
+	assert.Equal(t, 48, report.Succeeded())
+	assert.Equal(t, 0, report.Canceled())
+	if failed := report.Failed(); assert.Len(t, failed, 2) {
+		assert.Equal(t, "7", failed[0].ID)
+		assert.Equal(t, "9", failed[1].ID)
+	}
+	assert.Equal(t, "7", report.Results[7].ID, "Expected results in the same order as the items")
+	assert.EqualError(t, report.Errors()["9"], "not found")
+}
+
+func Test_RunBulkCanceled(t *testing.T) {
+	logger = logrus.New()
+	ctx, cancel := context.WithCancel(t.Context())
+	items := make([]int, 20)
+	for i := range items {
+		items[i] = i
+	}
+	var calls atomic.Int32
+	report := runBulk(ctx, items, strconv.Itoa, func(context.Context, int) error {
+		if calls.Add(1) == 2 {
+			cancel()
+		}
+		return nil
+	}, bulkOptions{MaxGoroutines: 1})
+
+	assert.Equal(t, int32(2), calls.Load(), "Expected no new items to start after cancellation")
+	assert.Equal(t, 2, report.Succeeded())
+	assert.Equal(t, 18, report.Canceled())
+	assert.Empty(t, report.Failed(), "Expected canceled items to not be reported as failed")
+}
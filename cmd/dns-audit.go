@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Cyb3r-Jak3/common/v5"
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	auditSubCommand = "audit"
+	noResolveFlag   = "no-resolve"
+
+	pagesSuffix  = ".pages.dev"
+	tunnelSuffix = ".cfargotunnel.com"
+	workerSuffix = ".workers.dev"
+)
+
+// dnsResolver looks up hostnames. It is satisfied by *net.Resolver and can be replaced in tests.
+type dnsResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// auditResolver is the resolver used by `dns-cleaner audit` to check that CNAME targets still exist.
+var auditResolver dnsResolver = net.DefaultResolver
+
+// workerPlaceholderContents are the addresses used for records that only exist to trigger Workers routes.
+var workerPlaceholderContents = []string{"192.0.2.1", "100::"}
+
+// dnsAuditor checks DNS records for targets that no longer exist.
+// A nil set means the resource could not be listed and that check is skipped.
+type dnsAuditor struct {
+	resolver      dnsResolver
+	pagesDomains  map[string]bool
+	tunnelIDs     map[string]bool
+	workerScripts map[string]bool
+	workerRoutes  []string
+}
+
+// danglingRecord is a DNS record that points at something that no longer exists.
+type danglingRecord struct {
+	Record cloudflare.DNSRecord
+	Reason string
+}
+
+// AuditDNS finds dangling DNS records and writes a DNS file with them marked for removal.
+func AuditDNS(ctx context.Context, c *cli.Command) error {
+	if err := CheckAPITokenPermission(ctx, DNSWrite); err != nil {
+		return err
+	}
+	if common.FileExists(dnsFilePath(c)) && c.Bool(noOverwriteFlag) {
+		return errors.New("existing DNS file found and no overwrite flag is set")
+	}
+	if err := GetZoneID(ctx, c); err != nil {
+		return err
+	}
+
+	records, err := ListAllDNSRecords(ctx, zoneRC, dnsListParams(c))
+	if err != nil {
+		logger.WithError(err).Errorln("Error getting DNS records")
+		return err
+	}
+
+	auditor := loadDNSAuditor(ctx, zoneRC)
+	if c.Bool(noResolveFlag) {
+		auditor.resolver = nil
+	}
+
+	recordFile := &RecordFile{
+		ZoneID:       zoneRC.Identifier,
+		ZoneName:     strings.TrimSpace(c.String(zoneNameFlag)),
+		DownloadedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	var dangling []danglingRecord
+	for _, record := range records {
+		fileRecord := newDNSRecord(record, true)
+		if reason, found := auditor.Check(ctx, record); found {
+			dangling = append(dangling, danglingRecord{Record: record, Reason: reason})
+			fileRecord.Keep = false
+			fileRecord.Rule = "audit: " + reason
+		}
+		recordFile.Records = append(recordFile.Records, fileRecord)
+	}
+
+	if len(dangling) == 0 {
+		fmt.Printf("No dangling records found in %d records\n", len(records))
+	} else {
+		fmt.Printf("Found %d dangling records:\n", len(dangling))
+		for _, finding := range dangling {
+			fmt.Printf("  %s\t%s\t-> %s\t%s\n", finding.Record.Type, finding.Record.Name, finding.Record.Content, finding.Reason)
+		}
+	}
+	if err := writeRecordFile(c, recordFile); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote DNS file to %s. Review it and run `dns-cleaner upload` to remove the dangling records\n", dnsFilePath(c))
+	return nil
+}
+
+// loadDNSAuditor lists the Pages projects, tunnels, Workers and Workers routes to check records against.
+// Resources that cannot be listed, for example because the token lacks permission, are skipped with a warning.
+func loadDNSAuditor(ctx context.Context, rc *cloudflare.ResourceContainer) *dnsAuditor {
+	auditor := &dnsAuditor{resolver: auditResolver}
+	if routes, err := APIClient.ListWorkerRoutes(ctx, rc, cloudflare.ListWorkerRoutesParams{}); err != nil {
+		logger.WithError(err).Warning("Unable to list Workers routes. Skipping Workers route checks")
+	} else {
+		// A zone without routes still has to be checked, because every placeholder record in it is dangling.
+		auditor.workerRoutes = make([]string, 0, len(routes.Routes))
+		for _, route := range routes.Routes {
+			auditor.workerRoutes = append(auditor.workerRoutes, route.Pattern)
+		}
+	}
+
+	if accountRC == nil {
+		logger.Warning("No account ID set. Skipping Pages, Tunnel and Workers checks")
+		return auditor
+	}
+	if projects, err := ListAllPagesProjects(ctx); err != nil {
+		logger.WithError(err).Warning("Unable to list Pages projects. Skipping Pages checks")
+	} else {
+		auditor.pagesDomains = make(map[string]bool, len(projects))
+		for _, project := range projects {
+			auditor.pagesDomains[strings.ToLower(project.SubDomain)] = true
+		}
+	}
+	if tunnels, _, err := APIClient.ListTunnels(ctx, accountRC, cloudflare.TunnelListParams{IsDeleted: cloudflare.BoolPtr(false)}); err != nil {
+		logger.WithError(err).Warning("Unable to list tunnels. Skipping tunnel checks")
+	} else {
+		auditor.tunnelIDs = make(map[string]bool, len(tunnels))
+		for _, tunnel := range tunnels {
+			auditor.tunnelIDs[strings.ToLower(tunnel.ID)] = true
+		}
+	}
+	if workers, _, err := APIClient.ListWorkers(ctx, accountRC, cloudflare.ListWorkersParams{}); err != nil {
+		logger.WithError(err).Warning("Unable to list Workers. Skipping Workers checks")
+	} else {
+		auditor.workerScripts = make(map[string]bool, len(workers.WorkerList))
+		for _, worker := range workers.WorkerList {
+			auditor.workerScripts[strings.ToLower(worker.ID)] = true
+		}
+	}
+	return auditor
+}
+
+// Check returns the reason a record is dangling.
+func (a *dnsAuditor) Check(ctx context.Context, record cloudflare.DNSRecord) (string, bool) {
+	if (record.Type == "A" || record.Type == "AAAA") && a.workerRoutes != nil && common.StringSearch(record.Content, workerPlaceholderContents) {
+		if !a.hasWorkerRoute(record.Name) {
+			return "placeholder record has no Workers route", true
+		}
+		return "", false
+	}
+	if record.Type != "CNAME" {
+		return "", false
+	}
+	target := strings.TrimSuffix(strings.ToLower(record.Content), ".")
+	switch {
+	case strings.HasSuffix(target, pagesSuffix):
+		if a.pagesDomains == nil {
+			return "", false
+		}
+		// Branch aliases point at <branch>.<project>.pages.dev
+		labels := strings.Split(strings.TrimSuffix(target, pagesSuffix), ".")
+		projectDomain := labels[len(labels)-1] + pagesSuffix
+		if !a.pagesDomains[projectDomain] {
+			return fmt.Sprintf("pages project %s does not exist", projectDomain), true
+		}
+	case strings.HasSuffix(target, tunnelSuffix):
+		if a.tunnelIDs == nil {
+			return "", false
+		}
+		tunnelID := strings.TrimSuffix(target, tunnelSuffix)
+		if !a.tunnelIDs[tunnelID] {
+			return fmt.Sprintf("tunnel %s does not exist", tunnelID), true
+		}
+	case strings.HasSuffix(target, workerSuffix):
+		if a.workerScripts == nil {
+			return "", false
+		}
+		script, _, _ := strings.Cut(target, ".")
+		if !a.workerScripts[script] {
+			return fmt.Sprintf("worker %s does not exist", script), true
+		}
+	default:
+		if a.resolver == nil {
+			return "", false
+		}
+		_, err := a.resolver.LookupHost(ctx, target)
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return fmt.Sprintf("target %s does not resolve", target), true
+		}
+		if err != nil {
+			logger.WithError(err).Warnf("Unable to resolve %s. Skipping", target)
+		}
+	}
+	return "", false
+}
+
+// hasWorkerRoute checks if any Workers route pattern matches the hostname.
+func (a *dnsAuditor) hasWorkerRoute(hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, pattern := range a.workerRoutes {
+		host, _, _ := strings.Cut(strings.ToLower(pattern), "/")
+		if matched, _ := path.Match(host, hostname); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if host == "timeout.example.net" {
+		return nil, &net.DNSError{Err: "i/o timeout", Name: host, IsTimeout: true}
+	}
+	addresses, ok := f[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addresses, nil
+}
+
+func Test_DNSAuditorCheck(t *testing.T) {
+	logger = logrus.New()
+	auditor := &dnsAuditor{
+		resolver:      fakeResolver{"live.example.net": {"198.51.100.4"}},
+		pagesDomains:  map[string]bool{"docs.pages.dev": true},
+		tunnelIDs:     map[string]bool{"f174e90a-fafe-4643-bbbc-4a0ed4fc8415": true},
+		workerScripts: map[string]bool{"api": true},
+		workerRoutes:  []string{"*.example.com/api/*"},
+	}
+	testCases := []struct {
+		name   string
+		record cloudflare.DNSRecord
+		reason string
+	}{
+		{name: "Live pages project", record: cloudflare.DNSRecord{Type: "CNAME", Content: "docs.pages.dev"}},
+		{name: "Pages branch alias", record: cloudflare.DNSRecord{Type: "CNAME", Content: "staging.docs.pages.dev"}},
+		{name: "Deleted pages project", record: cloudflare.DNSRecord{Type: "CNAME", Content: "old-docs.pages.dev"}, reason: "pages project old-docs.pages.dev does not exist"},
+		{name: "Live tunnel", record: cloudflare.DNSRecord{Type: "CNAME", Content: "f174e90a-fafe-4643-bbbc-4a0ed4fc8415.cfargotunnel.com"}},
+		{name: "Deleted tunnel", record: cloudflare.DNSRecord{Type: "CNAME", Content: "f174e90a-fafe-4643-bbbc-4a0ed4fc8416.cfargotunnel.com."}, reason: "tunnel f174e90a-fafe-4643-bbbc-4a0ed4fc8416 does not exist"},
+		{name: "Live worker", record: cloudflare.DNSRecord{Type: "CNAME", Content: "api.example.workers.dev"}},
+		{name: "Deleted worker", record: cloudflare.DNSRecord{Type: "CNAME", Content: "old-api.example.workers.dev"}, reason: "worker old-api does not exist"},
+		{name: "Resolving target", record: cloudflare.DNSRecord{Type: "CNAME", Content: "live.example.net"}},
+		{name: "NXDOMAIN target", record: cloudflare.DNSRecord{Type: "CNAME", Content: "gone.example.net"}, reason: "target gone.example.net does not resolve"},
+		{name: "Lookup failure", record: cloudflare.DNSRecord{Type: "CNAME", Content: "timeout.example.net"}},
+		{name: "Routed placeholder", record: cloudflare.DNSRecord{Type: "A", Name: "www.example.com", Content: "192.0.2.1"}},
+		{name: "Unrouted placeholder", record: cloudflare.DNSRecord{Type: "AAAA", Name: "example.com", Content: "100::"}, reason: "placeholder record has no Workers route"},
+		{name: "Normal A record", record: cloudflare.DNSRecord{Type: "A", Name: "example.com", Content: "198.51.100.4"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, dangling := auditor.Check(context.Background(), tc.record)
+			assert.Equal(t, tc.reason != "", dangling)
+			assert.Equal(t, tc.reason, reason)
+		})
+	}
+
+	skipped := &dnsAuditor{}
+	for _, tc := range testCases {
+		_, dangling := skipped.Check(context.Background(), tc.record)
+		assert.False(t, dangling, "Expected checks to be skipped when resources could not be listed")
+	}
+}
+
+func Test_DNSAuditorNoWorkerRoutes(t *testing.T) {
+	logger = logrus.New()
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/zones/2/workers/routes", r.URL.Path)
+		w.Header().Set("content-type", "application/json")
+		fmt.Fprint(w, `{"success": true, "errors": [], "messages": [], "result": []}`)
+	}))
+	defer testServer.Close()
+
+	var err error
+	APIClient, err = cloudflare.NewWithAPIToken("exampletoken", cloudflare.BaseURL(testServer.URL))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defaultAccountRC := accountRC
+	accountRC = nil
+	defer func() { accountRC = defaultAccountRC }()
+
+	auditor := loadDNSAuditor(t.Context(), cloudflare.ZoneIdentifier("2"))
+	assert.NotNil(t, auditor.workerRoutes, "Expected an empty list of routes when the zone has none")
+	reason, dangling := auditor.Check(t.Context(), cloudflare.DNSRecord{Type: "A", Name: "www.example.com", Content: "192.0.2.1"})
+	assert.True(t, dangling, "Expected a placeholder record in a zone without routes to be dangling")
+	assert.Equal(t, "placeholder record has no Workers route", reason)
+}
+
+func Test_DNSCleanerAudit(t *testing.T) {
+	defaultResolver := auditResolver
+	auditResolver = fakeResolver{}
+	defer func() { auditResolver = defaultResolver }()
+
+	outputFileName := "audit.yaml"
+	err := withApp(t, []string{"cloudflare-utils", "dns-cleaner", "audit", "--zone-id", "2", "--dns-file", outputFileName})
+	assert.NoError(t, err, "Expected no error when auditing records")
+	defer os.Remove(outputFileName)
+
+	data, err := os.ReadFile(outputFileName)
+	if !assert.NoError(t, err, "Expected the audit to write a DNS file") {
+		return
+	}
+	recordFile := &RecordFile{}
+	if assert.NoError(t, yaml.Unmarshal(data, recordFile)) && assert.Len(t, recordFile.Records, 1) {
+		assert.True(t, recordFile.Records[0].Keep, "Expected records that are not dangling to be kept")
+	}
+
+	err = withApp(t, []string{"cloudflare-utils", "dns-cleaner", "audit", "--zone-id", "2", "--dns-file", outputFileName, "--no-overwrite"})
+	assert.EqualError(t, err, "existing DNS file found and no overwrite flag is set")
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	backupDirFlag  = "backup-dir"
+	noBackupFlag   = "no-backup"
+	backupFileFlag = "backup-file"
+)
+
+// dnsBackupFlags are the flags of commands that back up DNS records before deleting them.
+func dnsBackupFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    backupDirFlag,
+			Usage:   "Directory to write a backup of DNS records to before they are deleted or updated",
+			Sources: cli.EnvVars("DNS_BACKUP_DIR"),
+			Value:   "./dns-backups",
+		},
+		&cli.BoolFlag{
+			Name:  noBackupFlag,
+			Usage: "Do not back up DNS records before they are deleted or updated",
+			Value: false,
+		},
+	}
+}
+
+// buildDNSRestoreCommand creates the dns-restore command.
+func buildDNSRestoreCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "dns-restore",
+		Usage:     "Recreate DNS records from a backup written by dns-purge or dns-cleaner.\nAPI Token Requirements: DNS:Edit",
+		ArgsUsage: "<backup file>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  backupFileFlag,
+				Usage: "Path to the backup file. Can also be passed as an argument",
+			},
+			&cli.BoolFlag{
+				Name:  dryRunFlag,
+				Usage: "Only show the records that would be restored",
+				Value: false,
+			},
+		},
+		Action: DNSRestore,
+	}
+}
+
+// backupDNSRecords writes the records to a timestamped file in the backup directory.
+// The backup is a DNS file with every record kept, so it can be restored with dns-restore.
+func backupDNSRecords(c *cli.Command, zoneID string, records []cloudflare.DNSRecord) (string, error) {
+	if len(records) == 0 {
+		return "", nil
+	}
+	if c.Bool(noBackupFlag) {
+		logger.Warnf("Not backing up %d DNS records", len(records))
+		return "", nil
+	}
+	now := time.Now().UTC()
+	recordFile := &RecordFile{
+		ZoneName:     strings.TrimSpace(c.String(zoneNameFlag)),
+		ZoneID:       zoneID,
+		DownloadedAt: now.Truncate(time.Second),
+	}
+	for _, record := range records {
+		recordFile.Records = append(recordFile.Records, newDNSRecord(record, true))
+	}
+	data, err := yaml.Marshal(recordFile)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling DNS backup: %w", err)
+	}
+	backupDir := c.String(backupDirFlag)
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return "", fmt.Errorf("error creating DNS backup directory: %w", err)
+	}
+	backupPath := filepath.Join(backupDir, fmt.Sprintf("dns-backup-%s-%s.yml", zoneID, now.Format("20060102T150405.000Z")))
+	if err := os.WriteFile(backupPath, data, 0600); err != nil {
+		return "", fmt.Errorf("error writing DNS backup: %w", err)
+	}
+	fmt.Printf("Backed up %d DNS records to %s\n", len(records), backupPath)
+	return backupPath, nil
+}
+
+// DNSRestore recreates the records in a backup that no longer exist and reverts records that were changed.
+func DNSRestore(ctx context.Context, c *cli.Command) error {
+	if err := CheckAPITokenPermission(ctx, DNSWrite); err != nil {
+		return err
+	}
+	backupPath := c.String(backupFileFlag)
+	if backupPath == "" {
+		backupPath = c.Args().First()
+		if backupPath == "" {
+			return errors.New("backup file must be provided as an argument or with --backup-file")
+		}
+	}
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("error reading DNS backup: %w", err)
+	}
+	backup := &RecordFile{}
+	if err := yaml.Unmarshal(data, backup); err != nil {
+		return fmt.Errorf("error parsing DNS backup: %w", err)
+	}
+	if backup.ZoneID == "" {
+		if err := GetZoneID(ctx, c); err != nil {
+			return err
+		}
+		backup.ZoneID = zoneRC.Identifier
+	}
+	zoneResource := cloudflare.ZoneIdentifier(backup.ZoneID)
+	liveRecords, err := ListAllDNSRecords(ctx, zoneResource, cloudflare.ListDNSRecordsParams{})
+	if err != nil {
+		logger.WithError(err).Errorln("Error getting current DNS records")
+		return err
+	}
+
+	liveIDs := make(map[string]bool, len(liveRecords))
+	for _, record := range liveRecords {
+		liveIDs[record.ID] = true
+	}
+	for i := range backup.Records {
+		backup.Records[i].Keep = true
+		// Deleted records get a new ID when they are recreated. Clearing the old one matches them to identical records that were already recreated.
+		if !liveIDs[backup.Records[i].ID] {
+			backup.Records[i].ID = ""
+		}
+	}
+	backup.DownloadedAt = time.Time{}
+
+	plan := buildDNSPlan(backup, liveRecords)
+	if len(plan.Changes) == 0 {
+		fmt.Println("Nothing to restore. The zone already has every record in the backup")
+		return nil
+	}
+	plan.Print(os.Stdout)
+	if c.Bool(dryRunFlag) {
+		return nil
+	}
+
+	toCreate := plan.Records(dnsCreateAction)
+	toUpdate := plan.Records(dnsUpdateAction)
+	restoreErrors := applyDNSChanges(ctx, zoneResource, toCreate, toUpdate)
+	if len(restoreErrors) != 0 {
+		for name, restoreErr := range restoreErrors {
+			logger.Infof("Error restoring record: %s: %s\n", name, restoreErr)
+		}
+		return fmt.Errorf("error restoring %d dns records", len(restoreErrors))
+	}
+	fmt.Printf("Successfully restored %d and reverted %d dns records\n", len(toCreate), len(toUpdate))
+	return nil
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_DNSPurgeBackup(t *testing.T) {
+	backupDir := t.TempDir()
+	err := withApp(t, []string{"cloudflare-utils", "dns-purge", "--confirm", "--backup-dir", backupDir})
+	assert.NoError(t, err, "Expected no error when purging records")
+
+	backups, _ := filepath.Glob(filepath.Join(backupDir, "dns-backup-2-*.yml"))
+	if !assert.Len(t, backups, 1, "Expected a backup to be written before deleting records") {
+		return
+	}
+	data, err := os.ReadFile(backups[0])
+	if !assert.NoError(t, err) {
+		return
+	}
+	backup := &RecordFile{}
+	if assert.NoError(t, yaml.Unmarshal(data, backup)) && assert.Len(t, backup.Records, 1) {
+		assert.Equal(t, "2", backup.ZoneID)
+		assert.Equal(t, DNSRecord{
+			ID: "372e67954025e0ba6aaa6d586b9e0b59", Keep: true, Name: "example.com", Type: "A", Content: "198.51.100.4", TTL: 120,
+			Proxied: backup.Records[0].Proxied, ModifiedOn: backup.Records[0].ModifiedOn,
+		}, backup.Records[0])
+	}
+
+	err = withApp(t, []string{"cloudflare-utils", "dns-restore", backups[0]})
+	assert.NoError(t, err, "Expected no error when the records in the backup still exist")
+
+	noBackupDir := t.TempDir()
+	err = withApp(t, []string{"cloudflare-utils", "dns-purge", "--confirm", "--backup-dir", noBackupDir, "--no-backup"})
+	assert.NoError(t, err, "Expected no error when purging records without a backup")
+	backups, _ = filepath.Glob(filepath.Join(noBackupDir, "*"))
+	assert.Empty(t, backups, "Expected no backup with --no-backup")
+}
+
+func Test_DNSRestore(t *testing.T) {
+	backupFile := filepath.Join(t.TempDir(), "backup.yml")
+	backup := `zone_name: example.com
+zone_id: "2"
+downloaded_at: 2024-01-01T00:00:00Z
+records:
+    - id: 372e67954025e0ba6aaa6d586b9e0b59
+      keep: true
+      name: example.com
+      type: A
+      content: 198.51.100.5
+      ttl: 120
+      proxied: false
+    - id: 023e105f4ecef8ad9ca31a8372d0c353
+      keep: true
+      name: new.example.com
+      type: A
+      content: 198.51.100.5
+      ttl: 1
+      proxied: false
+`
+	if err := os.WriteFile(backupFile, []byte(backup), 0600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	err := withApp(t, []string{"cloudflare-utils", "dns-restore", "--backup-file", backupFile, "--dry-run"})
+	assert.NoError(t, err, "Expected no error when planning a restore")
+
+	err = withApp(t, []string{"cloudflare-utils", "dns-restore", backupFile})
+	assert.NoError(t, err, "Expected no error when restoring records")
+
+	err = withApp(t, []string{"cloudflare-utils", "dns-restore"})
+	assert.EqualError(t, err, "backup file must be provided as an argument or with --backup-file")
+}
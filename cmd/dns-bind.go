@@ -0,0 +1,561 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+const (
+	// bindHeaderPrefix starts the comment line that holds the zone information of a DNS file written as a zone file.
+	bindHeaderPrefix = "; cloudflare-utils"
+	// bindDeletePrefix marks a record in a zone file that should be removed from the zone.
+	bindDeletePrefix = ";DELETE "
+)
+
+// marshalBIND writes the DNS file as an RFC 1035 zone file.
+// Cloudflare specific fields are written as a comment at the end of each record and records marked for removal are commented out with a `;DELETE` prefix.
+func marshalBIND(recordFile *RecordFile) []byte {
+	var buf bytes.Buffer
+	header := []string{bindHeaderPrefix}
+	if recordFile.ZoneName != "" {
+		header = append(header, "zone_name="+recordFile.ZoneName)
+	}
+	if recordFile.ZoneID != "" {
+		header = append(header, "zone_id="+recordFile.ZoneID)
+	}
+	if !recordFile.DownloadedAt.IsZero() {
+		header = append(header, "downloaded_at="+recordFile.DownloadedAt.Format(time.RFC3339))
+	}
+	buf.WriteString(strings.Join(header, " ") + "\n")
+	if recordFile.ZoneName != "" {
+		fmt.Fprintf(&buf, "$ORIGIN %s.\n", strings.TrimSuffix(recordFile.ZoneName, "."))
+	}
+	for _, record := range recordFile.Records {
+		if !record.Keep {
+			buf.WriteString(bindDeletePrefix)
+		}
+		fmt.Fprintf(&buf, "%s.\t%d\tIN\t%s\t%s", record.Name, record.TTL, record.Type, bindRData(record))
+		if metadata := bindMetadata(record); metadata != "" {
+			buf.WriteString(" ; " + metadata)
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// bindRData returns the record data in zone file presentation format.
+func bindRData(record DNSRecord) string {
+	switch record.Type {
+	case "MX":
+		return fmt.Sprintf("%d %s", cloudflare.Uint16(record.Priority), bindFQDN(record.Content))
+	case "CNAME", "NS", "PTR":
+		return bindFQDN(record.Content)
+	case "TXT", "SPF":
+		return bindTXT(record.Content)
+	case "SRV":
+		if len(record.Data) > 0 {
+			return fmt.Sprintf("%v %v %v %s", record.Data["priority"], record.Data["weight"], record.Data["port"], bindFQDN(fmt.Sprint(record.Data["target"])))
+		}
+		return fmt.Sprintf("%d %s", cloudflare.Uint16(record.Priority), record.Content)
+	case "CAA":
+		if len(record.Data) > 0 {
+			return fmt.Sprintf("%v %v %s", record.Data["flags"], record.Data["tag"], bindQuote(fmt.Sprint(record.Data["value"])))
+		}
+	}
+	return record.Content
+}
+
+// bindMetadata returns the Cloudflare specific fields of the record that are not part of the zone file format.
+func bindMetadata(record DNSRecord) string {
+	var fields []string
+	if record.ID != "" {
+		fields = append(fields, "cf_id="+record.ID)
+	}
+	if !record.ModifiedOn.IsZero() {
+		fields = append(fields, "modified_on="+record.ModifiedOn.Format(time.RFC3339))
+	}
+	if record.Proxied != nil {
+		fields = append(fields, "cf_tags=cf-proxied:"+strconv.FormatBool(*record.Proxied))
+	}
+	if record.Comment != "" {
+		fields = append(fields, "comment="+strconv.Quote(record.Comment))
+	}
+	if len(record.Tags) > 0 {
+		fields = append(fields, "tags="+strconv.Quote(strings.Join(record.Tags, ",")))
+	}
+	if record.Rule != "" {
+		fields = append(fields, "rule="+strconv.Quote(record.Rule))
+	}
+	return strings.Join(fields, " ")
+}
+
+func bindFQDN(name string) string {
+	if name == "" || strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// bindQuote quotes a character string, splitting it into 255 byte strings as required by RFC 1035.
+func bindQuote(value string) string {
+	parts := splitCharacterString(value)
+	for i, part := range parts {
+		parts[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(part) + `"`
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitCharacterString splits a value into strings of at most 255 bytes without splitting a UTF-8 character.
+func splitCharacterString(value string) []string {
+	var parts []string
+	for len(value) > 255 {
+		cut := 255
+		for cut > 0 && !utf8.RuneStart(value[cut]) {
+			cut--
+		}
+		parts = append(parts, value[:cut])
+		value = value[cut:]
+	}
+	return append(parts, value)
+}
+
+// txtStrings returns the character strings of TXT record content the way they are served.
+// Content in the quoted format, such as `"v=DKIM1; k=rsa; " "p=MIIB..."`, is split into its strings, and strings longer than 255 bytes are split.
+// Zone files and the Cloudflare API can write the same strings either way, so TXT records are compared by their strings.
+func txtStrings(content string) []string {
+	values := []string{content}
+	if strings.HasPrefix(content, `"`) {
+		if fields, _, depth, err := bindSplitLine(content, 0); err == nil && depth == 0 && allQuoted(fields) {
+			values = values[:0]
+			for _, field := range fields {
+				values = append(values, bindUnquote(field))
+			}
+		}
+	}
+	var parts []string
+	for _, value := range values {
+		parts = append(parts, splitCharacterString(value)...)
+	}
+	return parts
+}
+
+func allQuoted(fields []string) bool {
+	for _, field := range fields {
+		if len(field) < 2 || !strings.HasPrefix(field, `"`) || !strings.HasSuffix(field, `"`) {
+			return false
+		}
+	}
+	return len(fields) > 0
+}
+
+// bindTXT returns TXT record content as quoted character strings. bindTXTContent is the reverse.
+func bindTXT(content string) string {
+	parts := txtStrings(content)
+	for i, part := range parts {
+		parts[i] = bindQuote(part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// bindTXTContent returns the TXT record content of the quoted strings of a zone file record.
+// Strings that are a single value split into 255 byte strings are joined, so content written by bindTXT is read back unchanged.
+// Other strings are kept in the quoted format, which is how the Cloudflare API stores TXT records with multiple strings.
+func bindTXTContent(rdata []string) string {
+	parts := make([]string, 0, len(rdata))
+	for _, field := range rdata {
+		parts = append(parts, bindUnquote(field))
+	}
+	joined := strings.Join(parts, "")
+	if len(parts) == 1 || slices.Equal(splitCharacterString(joined), parts) {
+		return joined
+	}
+	for i, part := range parts {
+		parts[i] = bindQuote(part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// bindLine is a logical line of a zone file. Parentheses can spread a logical line over multiple physical lines.
+type bindLine struct {
+	number  int
+	fields  []string
+	comment string
+	blank   bool // the line started with whitespace, so the owner is the previous owner
+	delete  bool
+}
+
+// unmarshalBIND parses an RFC 1035 zone file into the DNS file.
+// defaultOrigin is used for relative names when the file has no `$ORIGIN` or header.
+func unmarshalBIND(data []byte, recordFile *RecordFile, defaultOrigin string) error {
+	lines, err := bindLogicalLines(data)
+	if err != nil {
+		return err
+	}
+	origin := strings.TrimSuffix(defaultOrigin, ".")
+	defaultTTL := 1
+	previousOwner := ""
+	for _, line := range lines {
+		if strings.HasPrefix(line.comment, strings.TrimPrefix(bindHeaderPrefix, "; ")) && len(line.fields) == 0 {
+			for key, value := range bindCommentFields(line.comment) {
+				switch key {
+				case "zone_name":
+					recordFile.ZoneName = value
+					if origin == "" {
+						origin = strings.TrimSuffix(value, ".")
+					}
+				case "zone_id":
+					recordFile.ZoneID = value
+				case "downloaded_at":
+					if recordFile.DownloadedAt, err = time.Parse(time.RFC3339, value); err != nil {
+						return fmt.Errorf("line %d: invalid downloaded_at: %w", line.number, err)
+					}
+				}
+			}
+			continue
+		}
+		if len(line.fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(line.fields[0]) {
+		case "$ORIGIN":
+			if len(line.fields) != 2 {
+				return fmt.Errorf("line %d: $ORIGIN needs a single domain name", line.number)
+			}
+			origin = strings.TrimSuffix(bindAbsoluteName(line.fields[1], origin), ".")
+			continue
+		case "$TTL":
+			if len(line.fields) != 2 {
+				return fmt.Errorf("line %d: $TTL needs a single value", line.number)
+			}
+			if defaultTTL, err = parseBINDTTL(line.fields[1]); err != nil {
+				return fmt.Errorf("line %d: %w", line.number, err)
+			}
+			continue
+		case "$INCLUDE", "$GENERATE":
+			return fmt.Errorf("line %d: %s is not supported", line.number, line.fields[0])
+		}
+
+		fields := line.fields
+		owner := previousOwner
+		if !line.blank {
+			owner = bindAbsoluteName(fields[0], origin)
+			fields = fields[1:]
+		}
+		if owner == "" {
+			return fmt.Errorf("line %d: record has no owner name", line.number)
+		}
+		previousOwner = owner
+
+		record, err := parseBINDRecord(owner, fields, defaultTTL, origin)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line.number, err)
+		}
+		if record == nil {
+			continue
+		}
+		record.Keep = !line.delete
+		if err := record.applyBINDMetadata(bindCommentFields(line.comment)); err != nil {
+			return fmt.Errorf("line %d: %w", line.number, err)
+		}
+		recordFile.Records = append(recordFile.Records, *record)
+	}
+	if recordFile.ZoneName == "" {
+		recordFile.ZoneName = origin
+	}
+	return nil
+}
+
+// parseBINDRecord parses the TTL, class, type and data of a record.
+// SOA records are managed by Cloudflare and are skipped.
+func parseBINDRecord(owner string, fields []string, defaultTTL int, origin string) (*DNSRecord, error) {
+	record := &DNSRecord{Name: strings.TrimSuffix(owner, "."), TTL: defaultTTL}
+	// TTL and class can be in either order and are both optional.
+	for range 2 {
+		if len(fields) == 0 {
+			break
+		}
+		if strings.EqualFold(fields[0], "IN") {
+			fields = fields[1:]
+		} else if ttl, err := parseBINDTTL(fields[0]); err == nil {
+			record.TTL = ttl
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("record has no type")
+	}
+	record.Type = strings.ToUpper(fields[0])
+	rdata := fields[1:]
+	if len(rdata) == 0 {
+		return nil, fmt.Errorf("%s record has no data", record.Type)
+	}
+	switch record.Type {
+	case "SOA":
+		return nil, nil
+	case "MX":
+		if len(rdata) != 2 {
+			return nil, errors.New("MX record needs a preference and an exchange")
+		}
+		priority, err := strconv.ParseUint(rdata[0], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MX preference: %w", err)
+		}
+		record.Priority = cloudflare.Uint16Ptr(uint16(priority))
+		record.Content = strings.TrimSuffix(bindAbsoluteName(rdata[1], origin), ".")
+	case "CNAME", "NS", "PTR":
+		record.Content = strings.TrimSuffix(bindAbsoluteName(rdata[0], origin), ".")
+	case "TXT", "SPF":
+		record.Content = bindTXTContent(rdata)
+	case "SRV":
+		if len(rdata) != 4 {
+			return nil, errors.New("SRV record needs a priority, weight, port and target")
+		}
+		numbers := make([]int, 3)
+		for i := range numbers {
+			number, err := strconv.ParseUint(rdata[i], 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SRV field %q: %w", rdata[i], err)
+			}
+			numbers[i] = int(number)
+		}
+		record.Priority = cloudflare.Uint16Ptr(uint16(numbers[0]))
+		record.Data = map[string]any{
+			"priority": numbers[0],
+			"weight":   numbers[1],
+			"port":     numbers[2],
+			"target":   strings.TrimSuffix(bindAbsoluteName(rdata[3], origin), "."),
+		}
+	case "CAA":
+		if len(rdata) != 3 {
+			return nil, errors.New("CAA record needs flags, a tag and a value")
+		}
+		flags, err := strconv.ParseUint(rdata[0], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CAA flags: %w", err)
+		}
+		record.Data = map[string]any{
+			"flags": int(flags),
+			"tag":   rdata[1],
+			"value": bindUnquote(rdata[2]),
+		}
+	default:
+		record.Content = strings.Join(rdata, " ")
+	}
+	return record, nil
+}
+
+// applyBINDMetadata sets the Cloudflare specific fields from the comment of a record.
+// Unknown keys are ignored so zone files from other providers can be read.
+func (r *DNSRecord) applyBINDMetadata(fields map[string]string) error {
+	if id, ok := fields["cf_id"]; ok {
+		r.ID = id
+	}
+	if modifiedOn, ok := fields["modified_on"]; ok {
+		parsed, err := time.Parse(time.RFC3339, modifiedOn)
+		if err != nil {
+			return fmt.Errorf("invalid modified_on: %w", err)
+		}
+		r.ModifiedOn = parsed
+	}
+	for _, tag := range strings.Split(fields["cf_tags"], ",") {
+		if proxied, ok := strings.CutPrefix(tag, "cf-proxied:"); ok {
+			r.Proxied = cloudflare.BoolPtr(proxied == "true")
+		}
+	}
+	if comment, ok := fields["comment"]; ok {
+		r.Comment = comment
+	}
+	if tags, ok := fields["tags"]; ok && tags != "" {
+		r.Tags = strings.Split(tags, ",")
+	}
+	if rule, ok := fields["rule"]; ok {
+		r.Rule = rule
+	}
+	return nil
+}
+
+// bindLogicalLines splits a zone file into logical lines, joining lines inside parentheses and separating comments.
+func bindLogicalLines(data []byte) ([]bindLine, error) {
+	var lines []bindLine
+	var current *bindLine
+	depth := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if current == nil {
+			current = &bindLine{number: number}
+			if rest, ok := cutPrefixFold(text, bindDeletePrefix); ok {
+				current.delete = true
+				text = rest
+			}
+			current.blank = text != "" && unicode.IsSpace(rune(text[0]))
+		}
+		fields, comment, newDepth, err := bindSplitLine(text, depth)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+		depth = newDepth
+		current.fields = append(current.fields, fields...)
+		if comment != "" {
+			current.comment = strings.TrimSpace(current.comment + " " + comment)
+		}
+		if depth == 0 {
+			lines = append(lines, *current)
+			current = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if depth != 0 {
+		return nil, errors.New("unbalanced parentheses at end of file")
+	}
+	return lines, nil
+}
+
+// bindSplitLine splits a physical line into fields and a comment. Quoted strings are kept as a single field including the quotes.
+func bindSplitLine(text string, depth int) (fields []string, comment string, newDepth int, err error) {
+	var field strings.Builder
+	inQuote, escaped, hasField := false, false, false
+	flush := func() {
+		if hasField {
+			fields = append(fields, field.String())
+			field.Reset()
+			hasField = false
+		}
+	}
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case escaped:
+			field.WriteByte(ch)
+			escaped = false
+		case ch == '\\':
+			field.WriteByte(ch)
+			escaped = true
+		case inQuote:
+			field.WriteByte(ch)
+			if ch == '"' {
+				inQuote = false
+			}
+		case ch == '"':
+			field.WriteByte(ch)
+			inQuote, hasField = true, true
+		case ch == ';':
+			flush()
+			return fields, strings.TrimSpace(text[i+1:]), depth, nil
+		case ch == '(':
+			flush()
+			depth++
+		case ch == ')':
+			flush()
+			if depth == 0 {
+				return nil, "", 0, errors.New("unexpected )")
+			}
+			depth--
+		case ch == ' ' || ch == '\t':
+			flush()
+		default:
+			field.WriteByte(ch)
+			hasField = true
+		}
+	}
+	if inQuote {
+		return nil, "", 0, errors.New("unterminated quoted string")
+	}
+	flush()
+	return fields, "", depth, nil
+}
+
+// bindCommentFields parses `key=value` pairs from a record comment. Values can be quoted.
+func bindCommentFields(comment string) map[string]string {
+	fields := make(map[string]string)
+	for comment != "" {
+		comment = strings.TrimLeft(comment, " \t")
+		key, rest, found := strings.Cut(comment, "=")
+		if !found || strings.ContainsAny(key, " \t") {
+			// Skip over free text
+			if _, after, more := strings.Cut(comment, " "); more {
+				comment = after
+				continue
+			}
+			break
+		}
+		if strings.HasPrefix(rest, `"`) {
+			if quoted, err := strconv.QuotedPrefix(rest); err == nil {
+				fields[key], _ = strconv.Unquote(quoted)
+				comment = rest[len(quoted):]
+				continue
+			}
+		}
+		value, after, _ := strings.Cut(rest, " ")
+		fields[key] = value
+		comment = after
+	}
+	return fields
+}
+
+// bindAbsoluteName resolves a zone file name against the origin. The result is always fully qualified.
+func bindAbsoluteName(name, origin string) string {
+	switch {
+	case name == "@":
+		return bindFQDN(origin)
+	case strings.HasSuffix(name, "."):
+		return name
+	case origin == "":
+		return bindFQDN(name)
+	}
+	return name + "." + bindFQDN(origin)
+}
+
+func bindUnquote(value string) string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, `"`), `"`)
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value)
+}
+
+// parseBINDTTL parses a TTL that is either a number of seconds or uses BIND units such as `1h30m`.
+func parseBINDTTL(value string) (int, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return seconds, nil
+	}
+	units := map[byte]int{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}
+	total, number, digits := 0, 0, false
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		if ch >= '0' && ch <= '9' {
+			number = number*10 + int(ch-'0')
+			digits = true
+			continue
+		}
+		multiplier, ok := units[byte(unicode.ToLower(rune(ch)))]
+		if !ok || !digits {
+			return 0, fmt.Errorf("invalid TTL: %s", value)
+		}
+		total += number * multiplier
+		number, digits = 0, false
+	}
+	if digits {
+		return 0, fmt.Errorf("invalid TTL: %s", value)
+	}
+	return total, nil
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):], true
+	}
+	return s, false
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BINDRoundTrip(t *testing.T) {
+	recordFile := &RecordFile{
+		ZoneName:     "example.com",
+		ZoneID:       "2",
+		DownloadedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Records: []DNSRecord{
+			{ID: "1", Keep: true, Name: "www.example.com", Type: "A", Content: "198.51.100.4", TTL: 1, Proxied: cloudflare.BoolPtr(true), Comment: "Web server", Tags: []string{"owner:web", "env:prod"}, ModifiedOn: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{ID: "2", Keep: false, Name: "old.example.com", Type: "CNAME", Content: "www.example.com", TTL: 1, Proxied: cloudflare.BoolPtr(false), Rule: "old records"},
+			{ID: "3", Keep: true, Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: 3600, Priority: cloudflare.Uint16Ptr(10)},
+			{ID: "4", Keep: true, Name: "example.com", Type: "TXT", Content: `v=spf1 include:"quoted" -all`, TTL: 3600},
+			{ID: "5", Keep: true, Name: "_sip._tcp.example.com", Type: "SRV", TTL: 3600, Priority: cloudflare.Uint16Ptr(10), Data: map[string]any{"priority": 10, "weight": 5, "port": 5060, "target": "sip.example.com"}},
+			{ID: "6", Keep: true, Name: "example.com", Type: "CAA", TTL: 3600, Data: map[string]any{"flags": 0, "tag": "issue", "value": "letsencrypt.org"}},
+			{ID: "7", Keep: true, Name: "long.example.com", Type: "TXT", Content: strings.Repeat("a", 300), TTL: 3600},
+			{ID: "8", Keep: true, Name: "dkim._domainkey.example.com", Type: "TXT", Content: `"v=DKIM1; k=rsa; " "p=MIIBIjANBgkq"`, TTL: 3600},
+		},
+	}
+	zoneFile := marshalBIND(recordFile)
+	assert.Contains(t, string(zoneFile), "long.example.com.\t3600\tIN\tTXT\t\""+strings.Repeat("a", 255)+"\" \""+strings.Repeat("a", 45)+"\"")
+	assert.Contains(t, string(zoneFile), "www.example.com.\t1\tIN\tA\t198.51.100.4 ; cf_id=1 modified_on=2023-01-01T00:00:00Z cf_tags=cf-proxied:true comment=\"Web server\" tags=\"owner:web,env:prod\"")
+	assert.Contains(t, string(zoneFile), ";DELETE old.example.com.\t1\tIN\tCNAME\twww.example.com.")
+
+	parsed := &RecordFile{}
+	err := unmarshalBIND(zoneFile, parsed, "")
+	if assert.NoError(t, err, "Expected no error parsing the written zone file") {
+		assert.Equal(t, recordFile, parsed, "Expected the zone file to round trip")
+	}
+}
+
+func Test_BINDForeignZoneFile(t *testing.T) {
+	zoneFile := `$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1.other-provider.net. hostmaster.example.com. (
+		2024010101 ; serial
+		7200       ; refresh
+		3600       ; retry
+		1209600    ; expire
+		3600 )     ; minimum
+@	300	IN	A	198.51.100.4
+	IN	MX	10 mail
+www	IN	CNAME	@
+txt	IN	TXT	"part one " "part two"
+;DELETE old	IN	A	198.51.100.9
+sub.other.net.	60	IN	AAAA	2001:db8::1 ; free text cf_tags=cf-proxied:true
+`
+	recordFile := &RecordFile{}
+	err := unmarshalBIND([]byte(zoneFile), recordFile, "")
+	if !assert.NoError(t, err, "Expected no error parsing a zone file from another provider") {
+		return
+	}
+	assert.Equal(t, "example.com", recordFile.ZoneName)
+	assert.Equal(t, []DNSRecord{
+		{Keep: true, Name: "example.com", Type: "A", Content: "198.51.100.4", TTL: 300},
+		{Keep: true, Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: 3600, Priority: cloudflare.Uint16Ptr(10)},
+		{Keep: true, Name: "www.example.com", Type: "CNAME", Content: "example.com", TTL: 3600},
+		{Keep: true, Name: "txt.example.com", Type: "TXT", Content: `"part one " "part two"`, TTL: 3600},
+		{Keep: false, Name: "old.example.com", Type: "A", Content: "198.51.100.9", TTL: 3600},
+		{Keep: true, Name: "sub.other.net", Type: "AAAA", Content: "2001:db8::1", TTL: 60, Proxied: cloudflare.BoolPtr(true)},
+	}, recordFile.Records)
+}
+
+func Test_BINDErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		zoneFile string
+		errMsg   string
+	}{
+		{name: "Unbalanced parentheses", zoneFile: "@ IN SOA a. b. ( 1 2 3\n", errMsg: "unbalanced parentheses at end of file"},
+		{name: "Bad MX", zoneFile: "@ IN MX mail.example.com.\n", errMsg: "line 1: MX record needs a preference and an exchange"},
+		{name: "Include", zoneFile: "$INCLUDE other.zone\n", errMsg: "line 1: $INCLUDE is not supported"},
+		{name: "Unterminated quote", zoneFile: "@ IN TXT \"open\n", errMsg: "line 1: unterminated quoted string"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := unmarshalBIND([]byte(tc.zoneFile), &RecordFile{}, "example.com")
+			assert.EqualError(t, err, tc.errMsg)
+		})
+	}
+}
+
+func Test_DNSCleanerBINDFormat(t *testing.T) {
+	outputFileName := "test.zone"
+	err := withApp(t, []string{"cloudflare-utils", "dns-cleaner", "download", "--zone-id", "2", "--dns-file", outputFileName, "--format", "bind"})
+	assert.NoError(t, err, "Expected no error when downloading records as a zone file")
+	defer os.Remove(outputFileName)
+
+	err = withApp(t, []string{"cloudflare-utils", "dns-cleaner", "upload", "--dns-file", outputFileName, "--format", "bind"})
+	assert.NoError(t, err, "Expected no error when uploading records from a zone file")
+
+	err = withApp(t, []string{"cloudflare-utils", "dns-cleaner", "download", "--zone-id", "2", "--format", "zone"})
+	assert.EqualError(t, err, "invalid format: zone. Valid formats are: yaml, bind")
+}
+
+func Test_BINDTXTRoundTripPlan(t *testing.T) {
+	long := "v=DKIM1; k=rsa; p=" + strings.Repeat("M", 400)
+	live := []cloudflare.DNSRecord{
+		{ID: "1", Type: "TXT", Name: "long.example.com", Content: long, TTL: 1},
+		{ID: "2", Type: "TXT", Name: "dkim.example.com", Content: `"v=DKIM1; k=rsa; " "p=MIIBIjANBgkq"`, TTL: 1},
+		{ID: "3", Type: "TXT", Name: "example.com", Content: `"v=spf1 -all"`, TTL: 1},
+	}
+	recordFile := &RecordFile{ZoneName: "example.com", ZoneID: "2"}
+	for _, record := range live {
+		recordFile.Records = append(recordFile.Records, newDNSRecord(record, true))
+	}
+
+	parsed := &RecordFile{}
+	if !assert.NoError(t, unmarshalBIND(marshalBIND(recordFile), parsed, "")) {
+		return
+	}
+	assert.Equal(t, long, parsed.Records[0].Content, "Expected a TXT record longer than 255 characters to be joined again")
+	assert.Equal(t, live[1].Content, parsed.Records[1].Content, "Expected the quoted strings to be kept")
+	assert.Empty(t, buildDNSPlan(parsed, live).Changes, "Expected no changes after exporting and importing TXT records")
+
+	// Records from a zone file of another provider have no ID and are matched by their strings.
+	for i := range parsed.Records {
+		parsed.Records[i].ID = ""
+	}
+	assert.Empty(t, buildDNSPlan(parsed, live).Changes, "Expected TXT records without an ID to match the live records")
+}
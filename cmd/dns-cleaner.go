@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Cyb3r-Jak3/common/v5"
 	"github.com/cloudflare/cloudflare-go"
@@ -18,26 +22,46 @@ import (
 const (
 	downloadSubCommand = "download"
 	uploadSubCommand   = "upload"
+	planSubCommand     = "plan"
 	dnsFileFlag        = "dns-file"
 	noKeepFlag         = "no-keep"
 	quickCleanFlag     = "quick-clean"
 	noOverwriteFlag    = "no-overwrite"
 	removeDNSFileFlag  = "remove-file"
+	dnsFormatFlag      = "format"
+	dnsRulesFileFlag   = "rules-file"
+	deleteMissingFlag  = "delete-missing"
+
+	yamlDNSFormat = "yaml"
+	bindDNSFormat = "bind"
 )
 
+// DNSRecord is a single record in the YAML DNS file.
+// It holds every editable field of a Cloudflare DNS record so the file can be used to restore records and not just delete them.
 type DNSRecord struct {
-	ID      string `yaml:"id"`
-	Keep    bool   `yaml:"keep"`
-	Name    string `yaml:"name"`
-	Type    string `yaml:"type"`
-	Content string `yaml:"content"`
+	ID       string         `yaml:"id,omitempty"`
+	Keep     bool           `yaml:"keep"`
+	Name     string         `yaml:"name"`
+	Type     string         `yaml:"type"`
+	Content  string         `yaml:"content,omitempty"`
+	TTL      int            `yaml:"ttl,omitempty"`
+	Proxied  *bool          `yaml:"proxied,omitempty"`
+	Priority *uint16        `yaml:"priority,omitempty"`
+	Comment  string         `yaml:"comment,omitempty"`
+	Tags     []string       `yaml:"tags,omitempty"`
+	Data     map[string]any `yaml:"data,omitempty"`
+	// ModifiedOn is when the record was last modified in the zone at the time of download.
+	ModifiedOn time.Time `yaml:"modified_on,omitempty"`
+	// Rule is the name of the rule that set the keep value when downloading.
+	Rule string `yaml:"rule,omitempty"`
 }
 
 // RecordFile is the struct of the YAML DNS file.
 type RecordFile struct {
-	ZoneName string      `yaml:"zone_name"`
-	ZoneID   string      `yaml:"zone_id"`
-	Records  []DNSRecord `yaml:"records"`
+	ZoneName     string      `yaml:"zone_name"`
+	ZoneID       string      `yaml:"zone_id"`
+	DownloadedAt time.Time   `yaml:"downloaded_at,omitempty"`
+	Records      []DNSRecord `yaml:"records"`
 }
 
 // buildDNSCleanerCommand builds the `dns-cleaner` command for the application.
@@ -57,8 +81,25 @@ func buildDNSCleanerCommand() *cli.Command {
 				Action: UploadDNS,
 				Usage:  "Upload dns records",
 			},
+			{
+				Name:   planSubCommand,
+				Action: PlanDNS,
+				Usage:  "Show the changes upload would make to the zone without making them",
+			},
+			{
+				Name:   auditSubCommand,
+				Action: AuditDNS,
+				Usage:  "Find dangling records and write a DNS file with them marked for removal",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  noResolveFlag,
+						Usage: "Do not resolve CNAME targets outside of Cloudflare",
+						Value: false,
+					},
+				},
+			},
 		},
-		Flags: []cli.Flag{
+		Flags: slices.Concat([]cli.Flag{
 			&cli.StringFlag{
 				Name:    dnsFileFlag,
 				Usage:   "Path to the DNS record file",
@@ -66,6 +107,17 @@ func buildDNSCleanerCommand() *cli.Command {
 				Sources: cli.EnvVars("DNS_RECORD_FILE"),
 				Value:   "./dns-records.yml",
 			},
+			&cli.StringFlag{
+				Name:  dnsFormatFlag,
+				Usage: fmt.Sprintf("Format of the DNS record file. Either %s or %s (RFC 1035 zone file). Defaults the DNS file to ./dns-records.zone for %s", yamlDNSFormat, bindDNSFormat, bindDNSFormat),
+				Value: yamlDNSFormat,
+				Action: func(_ context.Context, _ *cli.Command, s string) error {
+					if !common.StringSearch(s, []string{yamlDNSFormat, bindDNSFormat}) {
+						return fmt.Errorf("invalid format: %s. Valid formats are: %s, %s", s, yamlDNSFormat, bindDNSFormat)
+					}
+					return nil
+				},
+			},
 			&cli.BoolFlag{
 				Name:    noKeepFlag,
 				Usage:   "Mark records for removal by default",
@@ -79,6 +131,12 @@ func buildDNSCleanerCommand() *cli.Command {
 				Aliases: []string{"q"},
 				Sources: cli.EnvVars("QUICK_CLEAN"),
 			},
+			&cli.StringFlag{
+				Name:    dnsRulesFileFlag,
+				Usage:   "Path to a YAML rules file used to set the keep value of records when downloading. Records that match no rule use the default keep value",
+				Aliases: []string{"r"},
+				Sources: cli.EnvVars("DNS_RULES_FILE"),
+			},
 			&cli.BoolFlag{
 				Name:    noOverwriteFlag,
 				Usage:   "Do not replace existing DNS file",
@@ -90,12 +148,23 @@ func buildDNSCleanerCommand() *cli.Command {
 				Usage: "Remove the dns file once the upload completes",
 				Value: false,
 			},
+			&cli.BoolFlag{
+				Name:  forceFlag,
+				Usage: "Apply changes even if records in the zone were changed after the DNS file was downloaded",
+				Value: false,
+			},
+			&cli.BoolFlag{
+				Name: deleteMissingFlag,
+				Usage: "Delete the records in the zone that are not in the DNS file, so the zone matches the file. " +
+					"Only records that match the filter flags are deleted. Only applies to upload and plan",
+				Value: false,
+			},
 			&cli.BoolFlag{
 				Name:  dryRunFlag,
-				Usage: "Do not make any changes. Only applies to upload",
+				Usage: "Only show the plan of changes and do not make them. Only applies to upload",
 				Value: false,
 			},
-		},
+		}, dnsFilterFlags(), dnsBackupFlags()),
 	}
 }
 
@@ -108,7 +177,7 @@ func DNSCleaner(ctx context.Context, c *cli.Command) error {
 		return err
 	}
 
-	fileExists := common.FileExists(c.String(dnsFileFlag))
+	fileExists := common.FileExists(dnsFilePath(c))
 	logger.Debugf("Existing DNS file: %t\n", fileExists)
 	if !fileExists {
 		logger.Infoln("Downloading DNS Records")
@@ -135,7 +204,7 @@ func quickClean(zoneName, record string) bool {
 
 // DownloadDNS downloads current DNS records from Cloudflare.
 func DownloadDNS(ctx context.Context, c *cli.Command) error {
-	if common.FileExists(c.String(dnsFileFlag)) && c.Bool(noOverwriteFlag) {
+	if common.FileExists(dnsFilePath(c)) && c.Bool(noOverwriteFlag) {
 		return errors.New("existing DNS file found and no overwrite flag is set")
 	}
 
@@ -145,14 +214,15 @@ func DownloadDNS(ctx context.Context, c *cli.Command) error {
 	}
 	zoneName := strings.TrimSpace(c.String(zoneNameFlag))
 
-	records, _, err := APIClient.ListDNSRecords(ctx, zoneRC, cloudflare.ListDNSRecordsParams{})
+	records, err := ListAllDNSRecords(ctx, zoneRC, dnsListParams(c))
 	if err != nil {
-		logger.WithError(err).Errorln("Error getting zone info with ID")
+		logger.WithError(err).Errorln("Error getting DNS records")
 		return err
 	}
 	recordFile := &RecordFile{
-		ZoneID:   zoneRC.Identifier,
-		ZoneName: zoneName,
+		ZoneID:       zoneRC.Identifier,
+		ZoneName:     zoneName,
+		DownloadedAt: time.Now().UTC().Truncate(time.Second),
 	}
 	toKeep := !c.Bool(noKeepFlag)
 
@@ -162,92 +232,320 @@ func DownloadDNS(ctx context.Context, c *cli.Command) error {
 		return errors.New("using `--quick-clean` is not supported with `--no-keep`")
 	}
 
+	var rules *DNSRuleFile
+	if rulesFile := c.String(dnsRulesFileFlag); rulesFile != "" {
+		if rules, err = loadDNSRules(rulesFile); err != nil {
+			return err
+		}
+		logger.Debugf("Loaded %d DNS rules", len(rules.Rules))
+	}
+
 	for _, record := range records {
-		var keepValue bool
-		if useQuickClean {
+		keepValue := toKeep
+		ruleName := ""
+		if rule, matched := rules.Evaluate(record, recordFile.DownloadedAt); matched {
+			keepValue = *rule.Keep
+			ruleName = rule.Name
+		} else if useQuickClean {
 			keepValue = quickClean(zoneName, record.Name)
-		} else {
-			keepValue = toKeep
+			if !keepValue {
+				ruleName = quickCleanFlag
+			}
 		}
-		recordFile.Records = append(recordFile.Records, DNSRecord{
-			Name:    record.Name,
-			ID:      record.ID,
-			Type:    record.Type,
-			Keep:    keepValue,
-			Content: record.Content,
-		})
-	}
-	data, err := yaml.Marshal(&recordFile)
-	if err != nil {
-		logger.WithError(err).Errorln("Error marshalling yaml data")
-		return err
+		fileRecord := newDNSRecord(record, keepValue)
+		fileRecord.Rule = ruleName
+		recordFile.Records = append(recordFile.Records, fileRecord)
+	}
+	return writeRecordFile(c, recordFile)
+}
+
+// dnsFilePath returns the path of the DNS file.
+// Zone files default to a .zone extension when no path is given.
+func dnsFilePath(c *cli.Command) string {
+	if c.String(dnsFormatFlag) == bindDNSFormat && !c.IsSet(dnsFileFlag) {
+		return "./dns-records.zone"
 	}
-	if err := os.WriteFile(c.String(dnsFileFlag), data, 0600); err != nil {
+	return c.String(dnsFileFlag)
+}
+
+// writeRecordFile writes the DNS file in the format selected with `--format`.
+func writeRecordFile(c *cli.Command, recordFile *RecordFile) error {
+	var data []byte
+	if c.String(dnsFormatFlag) == bindDNSFormat {
+		data = marshalBIND(recordFile)
+	} else {
+		var err error
+		data, err = yaml.Marshal(&recordFile)
+		if err != nil {
+			logger.WithError(err).Errorln("Error marshalling yaml data")
+			return err
+		}
+	}
+	if err := os.WriteFile(dnsFilePath(c), data, 0600); err != nil {
 		logger.WithError(err).Errorln("Error writing DNS file")
 		return err
 	}
 	return nil
 }
 
-// UploadDNS makes the changes to DNS records based on the dns file.
-func UploadDNS(_ context.Context, c *cli.Command) error {
-	dnsFilePath := c.String(dnsFileFlag)
-	if !common.FileExists(dnsFilePath) {
-		return fmt.Errorf("no DNS file found at '%s'", dnsFilePath)
+// readRecordFile reads the DNS file in the format selected with `--format`.
+func readRecordFile(c *cli.Command) (*RecordFile, error) {
+	filePath := dnsFilePath(c)
+	if !common.FileExists(filePath) {
+		return nil, fmt.Errorf("no DNS file found at '%s'", filePath)
 	}
 
-	file, err := os.ReadFile(dnsFilePath)
+	file, err := os.ReadFile(filePath)
 	if err != nil {
 		logger.WithError(err).Errorln("Error reading DNS file")
-		return err
+		return nil, err
 	}
 
 	recordFile := &RecordFile{}
+	if c.String(dnsFormatFlag) == bindDNSFormat {
+		if err := unmarshalBIND(file, recordFile, c.String(zoneNameFlag)); err != nil {
+			logger.WithError(err).Errorln("Error parsing zone file")
+			return nil, fmt.Errorf("error parsing zone file: %w", err)
+		}
+		return recordFile, nil
+	}
 	if err := yaml.Unmarshal(file, recordFile); err != nil {
 		logger.WithError(err).Errorln("Error unmarshalling yaml")
+		return nil, err
+	}
+	return recordFile, nil
+}
+
+// newDNSRecord converts a Cloudflare DNS record into a DNS file record.
+func newDNSRecord(record cloudflare.DNSRecord, keep bool) DNSRecord {
+	fileRecord := DNSRecord{
+		ID:       record.ID,
+		Keep:     keep,
+		Name:     record.Name,
+		Type:     record.Type,
+		Content:  record.Content,
+		TTL:      record.TTL,
+		Proxied:  record.Proxied,
+		Priority: record.Priority,
+		Comment:  record.Comment,
+		Tags:     record.Tags,
+
+		ModifiedOn: record.ModifiedOn,
+	}
+	if data, ok := record.Data.(map[string]any); ok && len(data) > 0 {
+		fileRecord.Data = data
+	}
+	return fileRecord
+}
+
+// createParams builds the parameters to create the record.
+// Records with structured data (SRV, CAA, etc.) have their content generated by Cloudflare, so only the data is sent.
+func (r DNSRecord) createParams() cloudflare.CreateDNSRecordParams {
+	params := cloudflare.CreateDNSRecordParams{
+		Type:     r.Type,
+		Name:     r.Name,
+		TTL:      r.TTL,
+		Proxied:  r.Proxied,
+		Priority: r.Priority,
+		Comment:  r.Comment,
+		Tags:     r.Tags,
+	}
+	if len(r.Data) > 0 {
+		params.Data = r.Data
+	} else {
+		params.Content = r.Content
+	}
+	return params
+}
+
+// updateParams builds the parameters to update the record to match the file.
+func (r DNSRecord) updateParams() cloudflare.UpdateDNSRecordParams {
+	params := cloudflare.UpdateDNSRecordParams{
+		ID:       r.ID,
+		Type:     r.Type,
+		Name:     r.Name,
+		TTL:      r.TTL,
+		Proxied:  r.Proxied,
+		Priority: r.Priority,
+		Comment:  cloudflare.StringPtr(r.Comment),
+		Tags:     r.Tags,
+	}
+	if params.Tags == nil {
+		params.Tags = []string{}
+	}
+	if len(r.Data) > 0 {
+		params.Data = r.Data
+	} else {
+		params.Content = r.Content
+	}
+	return params
+}
+
+// changedFields returns the names of the fields that differ between the file record and the live record.
+func (r DNSRecord) changedFields(live cloudflare.DNSRecord) []string {
+	current := newDNSRecord(live, r.Keep)
+	var changed []string
+	if !strings.EqualFold(r.Name, current.Name) {
+		changed = append(changed, "name")
+	}
+	if r.Type != current.Type {
+		changed = append(changed, "type")
+	}
+	if len(r.Data) > 0 || len(current.Data) > 0 {
+		if !jsonEqual(r.Data, current.Data) {
+			changed = append(changed, "data")
+		}
+	} else if r.Type == "TXT" || r.Type == "SPF" {
+		if !slices.Equal(txtStrings(r.Content), txtStrings(current.Content)) {
+			changed = append(changed, "content")
+		}
+	} else if r.Content != current.Content {
+		changed = append(changed, "content")
+	}
+	if r.TTL != current.TTL {
+		changed = append(changed, "ttl")
+	}
+	if cloudflare.Bool(r.Proxied) != cloudflare.Bool(current.Proxied) {
+		changed = append(changed, "proxied")
+	}
+	if cloudflare.Uint16(r.Priority) != cloudflare.Uint16(current.Priority) {
+		changed = append(changed, "priority")
+	}
+	if r.Comment != current.Comment {
+		changed = append(changed, "comment")
+	}
+	if !slices.Equal(sortedCopy(r.Tags), sortedCopy(current.Tags)) {
+		changed = append(changed, "tags")
+	}
+	return changed
+}
+
+// jsonEqual compares two values by their JSON encoding.
+// YAML and JSON decode numbers to different types, so a direct comparison of the structured data is not reliable.
+func jsonEqual(a, b any) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aJSON, bJSON)
+}
+
+func sortedCopy(values []string) []string {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return sorted
+}
+
+// UploadDNS makes the changes to DNS records based on the dns file.
+// Records marked with `keep: false` are removed, records without a matching live record are created and records that differ from the live record are updated.
+func UploadDNS(ctx context.Context, c *cli.Command) error {
+	return uploadDNS(ctx, c, c.Bool(dryRunFlag))
+}
+
+// PlanDNS shows the changes that uploading the dns file would make without making them.
+func PlanDNS(ctx context.Context, c *cli.Command) error {
+	return uploadDNS(ctx, c, true)
+}
+
+func uploadDNS(ctx context.Context, c *cli.Command, dryRun bool) error {
+	recordFile, err := readRecordFile(c)
+	if err != nil {
 		return err
 	}
 
+	// Zone files from other providers do not have the zone ID.
+	if recordFile.ZoneID == "" {
+		if err := GetZoneID(ctx, c); err != nil {
+			return err
+		}
+		recordFile.ZoneID = zoneRC.Identifier
+	}
 	zoneResource := cloudflare.ZoneIdentifier(recordFile.ZoneID)
-	recordCount, errorCount := len(recordFile.Records), 0
-	var toRemove []cloudflare.DNSRecord
-
-	for _, record := range recordFile.Records {
-		if !record.Keep {
-			if c.Bool(dryRunFlag) {
-				fmt.Printf("Dry Run: Would have removed %s\n", record.Name)
-				continue
-			}
-			toRemove = append(toRemove, cloudflare.DNSRecord{
-				ID: record.ID,
-			})
+	liveRecords, err := ListAllDNSRecords(ctx, zoneResource, cloudflare.ListDNSRecordsParams{})
+	if err != nil {
+		logger.WithError(err).Errorln("Error getting current DNS records")
+		return err
+	}
+
+	recordCount := len(recordFile.Records)
+	plan := buildDNSPlan(recordFile, liveRecords)
+	if c.Bool(deleteMissingFlag) {
+		// Only the records that match the filter flags are deleted, so a DNS file downloaded with filters does not delete the rest of the zone.
+		inScope, err := ListAllDNSRecords(ctx, zoneResource, dnsListParams(c))
+		if err != nil {
+			return fmt.Errorf("error getting DNS records to delete: %w", err)
 		}
+		plan.DeleteMissing(inScope)
 	}
+	toCreate := plan.Records(dnsCreateAction)
+	toUpdate := plan.Records(dnsUpdateAction)
+	toRemove := plan.LiveRecords(dnsDeleteAction)
 
-	if c.Bool(dryRunFlag) {
-		fmt.Printf("Dry Run: Would have removed %d records\n", len(toRemove))
+	if len(plan.Changes) == 0 {
+		fmt.Println("No changes. The zone matches the DNS file")
+		return nil
+	}
+	plan.Print(os.Stdout)
+	if dryRun {
 		return nil
 	}
-	removeErrors := RapidDNSDelete(zoneResource, toRemove)
-	errorCount = len(removeErrors)
+	if diverged := plan.Diverged(); len(diverged) > 0 && !c.Bool(forceFlag) {
+		names := make([]string, 0, len(diverged))
+		for _, record := range diverged {
+			names = append(names, fmt.Sprintf("%s %s (%s)", record.Type, record.Name, record.ID))
+		}
+		return fmt.Errorf("%d records were changed in the zone after the DNS file was downloaded: %s. Download the records again or use `--%s` to apply anyway", len(diverged), strings.Join(names, ", "), forceFlag)
+	}
+
+	if _, err := backupDNSRecords(c, recordFile.ZoneID, append(plan.LiveRecords(dnsUpdateAction), toRemove...)); err != nil {
+		return err
+	}
+
+	uploadErrors := applyDNSChanges(ctx, zoneResource, toCreate, toUpdate)
+	deleteReport := RapidDNSDelete(ctx, zoneResource, toRemove)
+	for recordID, removeErr := range deleteReport.Errors() {
+		uploadErrors[recordID] = removeErr
+	}
+	errorCount := len(uploadErrors)
 
 	if errorCount == 0 {
-		fmt.Printf("Successfully deleted all %d dns records\n", len(toRemove))
+		fmt.Printf("Successfully created %d, updated %d and deleted %d dns records\n", len(toCreate), len(toUpdate), len(toRemove))
 	} else {
-		fmt.Printf("Error deleting %d dns records.\nPlease review errors and reach out if you believe to be an error with the program\n", errorCount)
+		fmt.Printf("Error changing %d dns records.\nPlease review errors and reach out if you believe to be an error with the program\n", errorCount)
 		if logger.IsLevelEnabled(logrus.InfoLevel) {
 			logger.Infoln("Errors:")
-			for deleteID, deleteErr := range removeErrors {
-				logger.Debugf("Error deleting record: %s: %s\n", deleteID, deleteErr)
+			for recordID, uploadErr := range uploadErrors {
+				logger.Infof("Error changing record: %s: %s\n", recordID, uploadErr)
 			}
 		}
 	}
 
-	logger.Infof("%d total records. %d to removed. %d errors removing records", recordCount, len(toRemove), errorCount)
+	logger.Infof("%d total records. %d to create, %d to update, %d to remove. %d errors changing records", recordCount, len(toCreate), len(toUpdate), len(toRemove), errorCount)
+	if errorCount > 0 {
+		return fmt.Errorf("failed to change %d of %d dns records", errorCount, len(toCreate)+len(toUpdate)+len(toRemove))
+	}
 	if c.Bool(removeDNSFileFlag) {
-		if err := os.Remove(dnsFilePath); err != nil {
+		if err := os.Remove(dnsFilePath(c)); err != nil {
 			logger.WithError(err).Warnln("Error deleting old DNS file")
 		}
 	}
 	return nil
 }
+
+// applyDNSChanges creates and updates DNS records one at a time.
+// It returns the errors keyed by the type, name and content for creates, because records such as TXT and MX can share a name,
+// and by the record ID for updates.
+func applyDNSChanges(ctx context.Context, rc *cloudflare.ResourceContainer, toCreate, toUpdate []DNSRecord) map[string]error {
+	changeErrors := make(map[string]error)
+	for _, record := range toCreate {
+		if _, createErr := APIClient.CreateDNSRecord(ctx, rc, record.createParams()); createErr != nil {
+			logger.WithError(createErr).Warningf("Error creating DNS record: %s\n", record.Name)
+			changeErrors[fmt.Sprintf("%s %s %s", record.Type, record.Name, record.Content)] = createErr
+		}
+	}
+	for _, record := range toUpdate {
+		if _, updateErr := APIClient.UpdateDNSRecord(ctx, rc, record.updateParams()); updateErr != nil {
+			logger.WithError(updateErr).Warningf("Error updating DNS record: %s\n", record.ID)
+			changeErrors[record.ID] = updateErr
+		}
+	}
+	return changeErrors
+}
@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sort"
 	"testing"
 
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -61,3 +67,105 @@ func Test_DNSCleaner(t *testing.T) {
 	removeErr := os.Remove(outputFileName)
 	assert.NoError(t, removeErr, "Expected no error when removing the output file")
 }
+
+func Test_DNSCleanerUploadCreateAndUpdate(t *testing.T) {
+	outputFileName := "restore.yaml"
+	recordFile := `zone_name: example.com
+zone_id: "2"
+records:
+    - id: 372e67954025e0ba6aaa6d586b9e0b59
+      keep: true
+      name: example.com
+      type: A
+      content: 198.51.100.5
+      ttl: 120
+      proxied: false
+    - keep: true
+      name: new.example.com
+      type: A
+      content: 198.51.100.5
+      comment: restored
+`
+	err := os.WriteFile(outputFileName, []byte(recordFile), 0600)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(outputFileName)
+	err = withApp(t, []string{"cloudflare-utils", "dns-cleaner", "upload", "--dns-file", outputFileName})
+	assert.NoError(t, err, "Expected no error when creating and updating records from the dns file")
+
+	err = withApp(t, []string{"cloudflare-utils", "dns-cleaner", "upload", "--dns-file", outputFileName, "--dry-run"})
+	assert.NoError(t, err, "Expected no error when dry running the dns file upload")
+}
+
+func Test_DNSRecordChangedFields(t *testing.T) {
+	live := cloudflare.DNSRecord{
+		ID:      "1",
+		Type:    "SRV",
+		Name:    "_sip._tcp.example.com",
+		Content: "10 5060 sip.example.com",
+		TTL:     1,
+		Proxied: cloudflare.BoolPtr(false),
+		Data:    map[string]any{"priority": float64(10), "port": float64(5060), "target": "sip.example.com"},
+	}
+	record := newDNSRecord(live, true)
+	assert.Empty(t, record.changedFields(live), "Expected no changes for a record built from the live record")
+
+	record.Data = map[string]any{"priority": 10, "port": 5061, "target": "sip.example.com"}
+	record.Comment = "moved"
+	assert.Equal(t, []string{"data", "comment"}, record.changedFields(live))
+}
+
+func Test_DNSCleanerUploadErrors(t *testing.T) {
+	logger = logrus.New()
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"success": false, "errors": [{"code": 81058, "message": "An identical record already exists."}], "messages": [], "result": null}`)
+			return
+		}
+		fmt.Fprint(w, `{"success": true, "errors": [], "messages": [], "result": [], "result_info": {"page": 1, "per_page": 1000, "count": 0, "total_count": 0, "total_pages": 1}}`)
+	}))
+	defer testServer.Close()
+
+	var err error
+	APIClient, err = cloudflare.NewWithAPIToken("exampletoken", cloudflare.BaseURL(testServer.URL))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	records := []DNSRecord{
+		{Keep: true, Name: "example.com", Type: "TXT", Content: "v=spf1 -all"},
+		{Keep: true, Name: "example.com", Type: "TXT", Content: "google-site-verification=abc"},
+	}
+	changeErrors := applyDNSChanges(t.Context(), cloudflare.ZoneIdentifier("2"), records, nil)
+	keys := make([]string, 0, len(changeErrors))
+	for key := range changeErrors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	assert.Equal(t, []string{"TXT example.com google-site-verification=abc", "TXT example.com v=spf1 -all"}, keys,
+		"Expected the errors of records that share a name to be kept")
+
+	outputFileName := "upload-errors.yaml"
+	recordFile := `zone_name: example.com
+zone_id: "2"
+records:
+    - keep: true
+      name: example.com
+      type: TXT
+      content: v=spf1 -all
+    - keep: true
+      name: example.com
+      type: TXT
+      content: google-site-verification=abc
+`
+	if err := os.WriteFile(outputFileName, []byte(recordFile), 0600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(outputFileName)
+	err = buildDNSCleanerCommand().Run(t.Context(), []string{"dns-cleaner", "upload", "--dns-file", outputFileName, "--remove-file"})
+	assert.EqualError(t, err, "failed to change 2 of 2 dns records")
+	assert.FileExists(t, outputFileName, "Expected the DNS file to be kept when changes fail")
+}
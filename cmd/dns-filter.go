@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Cyb3r-Jak3/common/v5"
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	recordTypeFlag    = "type"
+	recordNameFlag    = "name"
+	recordContentFlag = "content"
+	recordCommentFlag = "comment"
+	recordTagFlag     = "tag"
+	recordProxiedFlag = "proxied"
+	recordMatchFlag   = "match"
+
+	dnsFilterCategory = "Record filters"
+	// dnsRecordsPerPage is the number of records requested per page when listing DNS records.
+	dnsRecordsPerPage = 1000
+)
+
+// dnsFilterFlags are the flags used to filter the DNS records listed from a zone.
+// Filters are sent to the API so only matching records are downloaded.
+func dnsFilterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     recordTypeFlag,
+			Usage:    "Only include records of this type, such as A or CNAME",
+			Category: dnsFilterCategory,
+		},
+		&cli.StringFlag{
+			Name:     recordNameFlag,
+			Usage:    "Only include records with this full name, such as www.example.com",
+			Category: dnsFilterCategory,
+		},
+		&cli.StringFlag{
+			Name:     recordContentFlag,
+			Usage:    "Only include records with this content",
+			Category: dnsFilterCategory,
+		},
+		&cli.StringFlag{
+			Name:     recordCommentFlag,
+			Usage:    "Only include records with this comment",
+			Category: dnsFilterCategory,
+		},
+		&cli.StringSliceFlag{
+			Name:     recordTagFlag,
+			Usage:    "Only include records with this tag, in the form name:value. Can be set multiple times",
+			Category: dnsFilterCategory,
+		},
+		&cli.BoolFlag{
+			Name:     recordProxiedFlag,
+			Usage:    "Only include proxied records. Use --proxied=false for records that are not proxied",
+			Category: dnsFilterCategory,
+		},
+		&cli.StringFlag{
+			Name:     recordMatchFlag,
+			Usage:    "Whether records must match all of the filters or any of them",
+			Value:    "all",
+			Category: dnsFilterCategory,
+			Action: func(_ context.Context, _ *cli.Command, s string) error {
+				if !common.StringSearch(s, []string{"all", "any"}) {
+					return fmt.Errorf("invalid match: %s. Valid values are: all, any", s)
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// dnsListParams builds the DNS record list parameters from the filter flags.
+func dnsListParams(c *cli.Command) cloudflare.ListDNSRecordsParams {
+	params := cloudflare.ListDNSRecordsParams{
+		Type:    strings.ToUpper(c.String(recordTypeFlag)),
+		Name:    c.String(recordNameFlag),
+		Content: c.String(recordContentFlag),
+		Comment: c.String(recordCommentFlag),
+		Tags:    c.StringSlice(recordTagFlag),
+	}
+	if c.IsSet(recordProxiedFlag) {
+		params.Proxied = cloudflare.BoolPtr(c.Bool(recordProxiedFlag))
+	}
+	if c.String(recordMatchFlag) == "any" {
+		params.Match = "any"
+		params.TagMatch = "any"
+	}
+	return params
+}
+
+// progressOutput is where the progress of long listings is written. It is stderr so it is shown at every log level
+// without mixing into output written to stdout. It is replaced in tests.
+var progressOutput io.Writer = os.Stderr
+
+// ListAllDNSRecords lists every DNS record in the zone that matches the params, one page at a time.
+// Progress is written after each page so large zones do not look stuck.
+func ListAllDNSRecords(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.ListDNSRecordsParams) ([]cloudflare.DNSRecord, error) {
+	params.PerPage = dnsRecordsPerPage
+	params.Page = 1
+	// A fixed order keeps pages stable. Records that still move between pages while listing are only kept once.
+	params.Order = "type"
+	var records []cloudflare.DNSRecord
+	seen := make(map[string]bool)
+	startListing := time.Now()
+	for {
+		res, resultInfo, err := APIClient.ListDNSRecords(ctx, rc, params)
+		if err != nil {
+			return nil, fmt.Errorf("api error listing dns records on page %d: %w", params.Page, err)
+		}
+		for _, record := range res {
+			if seen[record.ID] {
+				continue
+			}
+			seen[record.ID] = true
+			records = append(records, record)
+		}
+		if resultInfo.Total > len(res) {
+			fmt.Fprintf(progressOutput, "Listed %d of %d DNS records\n", len(records), resultInfo.Total)
+		}
+		if len(res) == 0 || !resultInfo.HasMorePages() {
+			break
+		}
+		params.Page = resultInfo.Page + 1
+	}
+	logger.Debugf("Got %d DNS records in %s", len(records), time.Since(startListing).Round(time.Millisecond))
+	return records, nil
+}
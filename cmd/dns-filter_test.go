@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ListAllDNSRecords(t *testing.T) {
+	logger = logrus.New()
+	pages := map[string]string{
+		"1": `[{"id": "1", "type": "A", "name": "a.example.com", "content": "198.51.100.1"}, {"id": "2", "type": "A", "name": "b.example.com", "content": "198.51.100.2"}]`,
+		"2": `[{"id": "2", "type": "A", "name": "b.example.com", "content": "198.51.100.2"}, {"id": "3", "type": "A", "name": "c.example.com", "content": "198.51.100.3"}]`,
+		"3": `[{"id": "4", "type": "A", "name": "d.example.com", "content": "198.51.100.4"}]`,
+	}
+	requests := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		query := r.URL.Query()
+		assert.Equal(t, "A", query.Get("type"), "Expected the type filter to be sent to the API")
+		assert.Equal(t, "true", query.Get("proxied"), "Expected the proxied filter to be sent to the API")
+		assert.Equal(t, []string{"owner:web", "env:prod"}, query["tag"], "Expected every tag filter to be sent to the API")
+		assert.Equal(t, "1000", query.Get("per_page"))
+		page := query.Get("page")
+		w.Header().Set("content-type", "application/json")
+		fmt.Fprintf(w, `{
+			"success": true,
+			"errors": [],
+			"messages": [],
+			"result": %s,
+			"result_info": {"page": %s, "per_page": 2, "count": 2, "total_count": 5, "total_pages": 3}
+		}`, pages[page], page)
+	}))
+	defer testServer.Close()
+
+	var err error
+	APIClient, err = cloudflare.NewWithAPIToken("exampletoken", cloudflare.BaseURL(testServer.URL))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defaultProgress := progressOutput
+	var progress bytes.Buffer
+	progressOutput = &progress
+	defer func() { progressOutput = defaultProgress }()
+	records, err := ListAllDNSRecords(t.Context(), cloudflare.ZoneIdentifier("2"), cloudflare.ListDNSRecordsParams{
+		Type:    "A",
+		Proxied: cloudflare.BoolPtr(true),
+		Tags:    []string{"owner:web", "env:prod"},
+	})
+	assert.NoError(t, err, "Expected no error listing every page of records")
+	assert.Equal(t, 3, requests, "Expected one request per page")
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	assert.Equal(t, []string{"1", "2", "3", "4"}, ids, "Expected records repeated across pages to be listed once")
+	assert.Equal(t, "Listed 2 of 5 DNS records\nListed 3 of 5 DNS records\nListed 4 of 5 DNS records\n", progress.String(),
+		"Expected progress after every page regardless of the log level")
+}
+
+func Test_DNSRecordFilterFlags(t *testing.T) {
+	outputFileName := "filtered.yaml"
+	err := withApp(t, []string{"cloudflare-utils", "dns-cleaner", "download", "--zone-id", "2", "--dns-file", outputFileName, "--type", "a", "--proxied=false", "--match", "any"})
+	assert.NoError(t, err, "Expected no error when downloading filtered records")
+	defer os.Remove(outputFileName)
+
+	err = withApp(t, []string{"cloudflare-utils", "dns-purge", "--confirm", "--match", "some"})
+	assert.EqualError(t, err, "invalid match: some. Valid values are: all, any")
+}
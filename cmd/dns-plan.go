@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+type dnsChangeAction string
+
+const (
+	dnsCreateAction dnsChangeAction = "create"
+	dnsUpdateAction dnsChangeAction = "update"
+	dnsDeleteAction dnsChangeAction = "delete"
+)
+
+// dnsChange is a single change that will be made to the zone to match the DNS file.
+type dnsChange struct {
+	Action dnsChangeAction
+	// Record is the record from the DNS file.
+	Record DNSRecord
+	// Live is the current record in the zone. It is nil for records that will be created.
+	Live *cloudflare.DNSRecord
+	// Fields are the fields that differ between the DNS file and the live record.
+	Fields []string
+	// Warnings are problems found while comparing the DNS file to the zone.
+	Warnings []string
+	// Diverged is set when the record was changed in the zone after the DNS file was downloaded.
+	Diverged bool
+}
+
+// dnsPlan is the set of changes needed to make the zone match the DNS file.
+type dnsPlan struct {
+	Changes []dnsChange
+	// Skipped are records marked for removal that no longer exist in the zone.
+	Skipped []DNSRecord
+	// snapshot is set when the DNS file records when it was downloaded.
+	snapshot bool
+	// claimed are the IDs of the live records that a record in the DNS file refers to or was matched to.
+	claimed map[string]bool
+}
+
+// buildDNSPlan compares the DNS file against the live records of the zone and works out what needs to change.
+func buildDNSPlan(recordFile *RecordFile, liveRecords []cloudflare.DNSRecord) dnsPlan {
+	liveByID := make(map[string]cloudflare.DNSRecord, len(liveRecords))
+	for _, record := range liveRecords {
+		liveByID[record.ID] = record
+	}
+
+	claimed := make(map[string]bool, len(recordFile.Records))
+	for _, record := range recordFile.Records {
+		if record.ID != "" {
+			claimed[record.ID] = true
+		}
+	}
+
+	plan := dnsPlan{snapshot: !recordFile.DownloadedAt.IsZero(), claimed: claimed}
+	for _, record := range recordFile.Records {
+		if record.ID == "" {
+			// Records without an ID, for example from a zone file of another provider, are matched to identical live records so they are not created twice.
+			if matched, ok := matchLiveRecord(record, liveRecords, claimed); ok {
+				record.ID = matched.ID
+				claimed[matched.ID] = true
+			}
+		}
+		live, exists := liveByID[record.ID]
+		switch {
+		case !record.Keep:
+			if record.ID == "" || !exists {
+				plan.Skipped = append(plan.Skipped, record)
+				continue
+			}
+			change := dnsChange{Action: dnsDeleteAction, Record: record, Live: &live}
+			if changed := record.changedFields(live); len(changed) > 0 {
+				change.Fields = changed
+				change.Diverged = true
+				change.Warnings = append(change.Warnings, fmt.Sprintf("live record has changed since download: %s", strings.Join(changed, ", ")))
+			}
+			change.checkModified(recordFile.DownloadedAt)
+			plan.Changes = append(plan.Changes, change)
+		case record.ID == "" || !exists:
+			change := dnsChange{Action: dnsCreateAction, Record: record}
+			if record.ID != "" {
+				change.Diverged = plan.snapshot
+				change.Warnings = append(change.Warnings, fmt.Sprintf("record ID %s no longer exists in the zone", record.ID))
+			}
+			plan.Changes = append(plan.Changes, change)
+		default:
+			if changed := record.changedFields(live); len(changed) > 0 {
+				change := dnsChange{Action: dnsUpdateAction, Record: record, Live: &live, Fields: changed}
+				change.checkModified(recordFile.DownloadedAt)
+				plan.Changes = append(plan.Changes, change)
+			}
+		}
+	}
+	return plan
+}
+
+// DeleteMissing adds a delete for every live record that no record in the DNS file refers to or matches, so the zone matches the file.
+func (p *dnsPlan) DeleteMissing(liveRecords []cloudflare.DNSRecord) {
+	for _, live := range liveRecords {
+		if p.claimed[live.ID] {
+			continue
+		}
+		p.Changes = append(p.Changes, dnsChange{
+			Action:   dnsDeleteAction,
+			Record:   newDNSRecord(live, false),
+			Live:     &live,
+			Warnings: []string{"not in the DNS file"},
+		})
+	}
+}
+
+// matchLiveRecord finds a live record with the same name, type and content that is not referenced by ID in the DNS file.
+func matchLiveRecord(record DNSRecord, liveRecords []cloudflare.DNSRecord, claimed map[string]bool) (cloudflare.DNSRecord, bool) {
+	for _, live := range liveRecords {
+		if claimed[live.ID] || live.Type != record.Type || !strings.EqualFold(live.Name, record.Name) {
+			continue
+		}
+		changed := record.changedFields(live)
+		if !slices.Contains(changed, "content") && !slices.Contains(changed, "data") {
+			return live, true
+		}
+	}
+	return cloudflare.DNSRecord{}, false
+}
+
+// checkModified marks the change as diverged if the live record was modified after it was downloaded.
+// The record's own modified time is used when available, otherwise the time the DNS file was downloaded.
+// DNS files without either are not checked.
+func (change *dnsChange) checkModified(downloadedAt time.Time) {
+	snapshot := change.Record.ModifiedOn
+	if snapshot.IsZero() {
+		snapshot = downloadedAt
+	}
+	if snapshot.IsZero() || change.Live == nil || !change.Live.ModifiedOn.After(snapshot) {
+		return
+	}
+	change.Diverged = true
+	change.Warnings = append(change.Warnings, fmt.Sprintf("modified in the zone at %s, after the DNS file snapshot at %s", change.Live.ModifiedOn.Format(time.RFC3339), snapshot.Format(time.RFC3339)))
+}
+
+// Diverged returns the records that were changed in the zone after the DNS file was downloaded.
+func (p dnsPlan) Diverged() []DNSRecord {
+	var records []DNSRecord
+	for _, change := range p.Changes {
+		if change.Diverged {
+			records = append(records, change.Record)
+		}
+	}
+	if p.snapshot {
+		for _, record := range p.Skipped {
+			if record.ID != "" {
+				records = append(records, record)
+			}
+		}
+	}
+	return records
+}
+
+// Records returns the records from the DNS file for all changes with the given action.
+func (p dnsPlan) Records(action dnsChangeAction) []DNSRecord {
+	var records []DNSRecord
+	for _, change := range p.Changes {
+		if change.Action == action {
+			records = append(records, change.Record)
+		}
+	}
+	return records
+}
+
+// LiveRecords returns the live records for all changes with the given action.
+func (p dnsPlan) LiveRecords(action dnsChangeAction) []cloudflare.DNSRecord {
+	var records []cloudflare.DNSRecord
+	for _, change := range p.Changes {
+		if change.Action == action && change.Live != nil {
+			records = append(records, *change.Live)
+		}
+	}
+	return records
+}
+
+// Print writes a terraform style diff of the plan.
+func (p dnsPlan) Print(w io.Writer) {
+	for _, change := range p.Changes {
+		switch change.Action {
+		case dnsCreateAction:
+			fmt.Fprintf(w, "  + create %s %s\n", change.Record.Type, change.Record.Name)
+			for _, field := range dnsRecordFields {
+				if field == "content" && len(change.Record.Data) > 0 {
+					continue
+				}
+				if value := change.Record.fieldValue(field); value != "" && value != `""` {
+					fmt.Fprintf(w, "      %s: %s\n", field, value)
+				}
+			}
+		case dnsUpdateAction:
+			fmt.Fprintf(w, "  ~ update %s %s (%s)\n", change.Record.Type, change.Record.Name, change.Record.ID)
+			current := newDNSRecord(*change.Live, true)
+			for _, field := range change.Fields {
+				fmt.Fprintf(w, "      %s: %s => %s\n", field, planValue(current.fieldValue(field)), planValue(change.Record.fieldValue(field)))
+			}
+		case dnsDeleteAction:
+			fmt.Fprintf(w, "  - delete %s %s (%s)\n", change.Live.Type, change.Live.Name, change.Live.ID)
+			current := newDNSRecord(*change.Live, false)
+			fmt.Fprintf(w, "      content: %s\n", current.fieldValue("content"))
+			for _, field := range change.Fields {
+				fmt.Fprintf(w, "      %s: %s (downloaded) => %s (live)\n", field, planValue(change.Record.fieldValue(field)), planValue(current.fieldValue(field)))
+			}
+		}
+		for _, warning := range change.Warnings {
+			fmt.Fprintf(w, "      ! %s\n", warning)
+		}
+	}
+	for _, record := range p.Skipped {
+		fmt.Fprintf(w, "  ! %s %s (%s) is marked for removal but no longer exists in the zone\n", record.Type, record.Name, record.ID)
+	}
+	fmt.Fprintf(w, "Plan: %d to create, %d to update, %d to delete.\n", len(p.Records(dnsCreateAction)), len(p.Records(dnsUpdateAction)), len(p.Records(dnsDeleteAction)))
+	if diverged := len(p.Diverged()); diverged > 0 {
+		fmt.Fprintf(w, "%d records have drifted from the DNS file. Review the warnings above before applying.\n", diverged)
+	}
+}
+
+// dnsRecordFields are the fields compared and shown in the plan, in display order.
+var dnsRecordFields = []string{"name", "type", "content", "data", "ttl", "proxied", "priority", "comment", "tags"}
+
+// fieldValue returns the display value of a field for the plan output.
+func (r DNSRecord) fieldValue(field string) string {
+	switch field {
+	case "name":
+		return r.Name
+	case "type":
+		return r.Type
+	case "content":
+		return strconv.Quote(r.Content)
+	case "data":
+		if len(r.Data) == 0 {
+			return ""
+		}
+		data, _ := json.Marshal(r.Data)
+		return string(data)
+	case "ttl":
+		if r.TTL == 0 {
+			return ""
+		}
+		if r.TTL == 1 {
+			return "auto"
+		}
+		return strconv.Itoa(r.TTL)
+	case "proxied":
+		if r.Proxied == nil {
+			return ""
+		}
+		return strconv.FormatBool(cloudflare.Bool(r.Proxied))
+	case "priority":
+		if r.Priority == nil {
+			return ""
+		}
+		return strconv.Itoa(int(*r.Priority))
+	case "comment":
+		return strconv.Quote(r.Comment)
+	case "tags":
+		if len(r.Tags) == 0 {
+			return ""
+		}
+		return "[" + strings.Join(r.Tags, ", ") + "]"
+	}
+	return ""
+}
+
+// planValue shows empty field values explicitly so removed values are visible in the plan.
+func planValue(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLiveRecords() []cloudflare.DNSRecord {
+	return []cloudflare.DNSRecord{
+		{ID: "1", Type: "A", Name: "www.example.com", Content: "198.51.100.4", TTL: 1, Proxied: cloudflare.BoolPtr(true)},
+		{ID: "2", Type: "A", Name: "old.example.com", Content: "198.51.100.9", TTL: 1, Proxied: cloudflare.BoolPtr(false)},
+		{ID: "3", Type: "TXT", Name: "example.com", Content: "v=spf1 -all", TTL: 3600, Proxied: cloudflare.BoolPtr(false)},
+	}
+}
+
+func Test_BuildDNSPlan(t *testing.T) {
+	recordFile := &RecordFile{
+		ZoneID: "2",
+		Records: []DNSRecord{
+			{ID: "1", Keep: true, Type: "A", Name: "www.example.com", Content: "198.51.100.5", TTL: 1, Proxied: cloudflare.BoolPtr(true)},
+			{ID: "2", Keep: false, Type: "A", Name: "old.example.com", Content: "198.51.100.8", TTL: 1, Proxied: cloudflare.BoolPtr(false)},
+			{ID: "3", Keep: true, Type: "TXT", Name: "example.com", Content: "v=spf1 -all", TTL: 3600, Proxied: cloudflare.BoolPtr(false)},
+			{Keep: true, Type: "CNAME", Name: "blog.example.com", Content: "example.com", TTL: 1},
+			{ID: "4", Keep: true, Type: "A", Name: "gone.example.com", Content: "198.51.100.7"},
+			{ID: "5", Keep: false, Type: "A", Name: "removed.example.com", Content: "198.51.100.6"},
+		},
+	}
+	plan := buildDNSPlan(recordFile, testLiveRecords())
+
+	assert.Len(t, plan.Records(dnsCreateAction), 2, "Expected records without a live ID to be created")
+	assert.Len(t, plan.Records(dnsUpdateAction), 1, "Expected only the changed record to be updated")
+	assert.Len(t, plan.LiveRecords(dnsDeleteAction), 1, "Expected the record marked for removal to be deleted")
+	assert.Len(t, plan.Skipped, 1, "Expected the missing record marked for removal to be skipped")
+	assert.Len(t, plan.Diverged(), 1, "Expected only the changed delete to diverge when the file has no snapshot")
+
+	var output bytes.Buffer
+	plan.Print(&output)
+	assert.Contains(t, output.String(), "  ~ update A www.example.com (1)\n      content: \"198.51.100.4\" => \"198.51.100.5\"")
+	assert.Contains(t, output.String(), "  + create CNAME blog.example.com")
+	assert.Contains(t, output.String(), "  - delete A old.example.com (2)")
+	assert.Contains(t, output.String(), "content: \"198.51.100.8\" (downloaded) => \"198.51.100.9\" (live)")
+	assert.Contains(t, output.String(), "record ID 4 no longer exists in the zone")
+	assert.Contains(t, output.String(), "Plan: 2 to create, 1 to update, 1 to delete.")
+}
+
+func Test_DNSCleanerPlan(t *testing.T) {
+	outputFileName := "plan.yaml"
+	err := withApp(t, []string{"cloudflare-utils", "dns-cleaner", "download", "--zone-id", "2", "--dns-file", outputFileName, "--no-keep"})
+	assert.NoError(t, err, "Expected no error when downloading records")
+	defer os.Remove(outputFileName)
+
+	err = withApp(t, []string{"cloudflare-utils", "dns-cleaner", "plan", "--dns-file", outputFileName})
+	assert.NoError(t, err, "Expected no error when planning the dns file upload")
+	assert.FileExists(t, outputFileName, "Expected plan to leave the dns file in place")
+}
+
+func Test_BuildDNSPlanModifiedAfterDownload(t *testing.T) {
+	downloadedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	live := testLiveRecords()
+	live[0].ModifiedOn = downloadedAt.Add(time.Hour)
+	live[1].ModifiedOn = downloadedAt.Add(-time.Hour)
+	recordFile := &RecordFile{
+		ZoneID:       "2",
+		DownloadedAt: downloadedAt,
+		Records: []DNSRecord{
+			{ID: "1", Keep: true, Type: "A", Name: "www.example.com", Content: "198.51.100.5", TTL: 1, Proxied: cloudflare.BoolPtr(true), ModifiedOn: downloadedAt.Add(-time.Hour)},
+			{ID: "2", Keep: false, Type: "A", Name: "old.example.com", Content: "198.51.100.9", TTL: 1, Proxied: cloudflare.BoolPtr(false), ModifiedOn: downloadedAt.Add(-time.Hour)},
+			{ID: "5", Keep: false, Type: "A", Name: "removed.example.com", Content: "198.51.100.6"},
+		},
+	}
+	plan := buildDNSPlan(recordFile, live)
+	diverged := plan.Diverged()
+	if assert.Len(t, diverged, 2, "Expected the record modified after download and the removed record to diverge") {
+		assert.Equal(t, "1", diverged[0].ID)
+		assert.Equal(t, "5", diverged[1].ID)
+	}
+}
+
+func Test_DNSCleanerUploadDrift(t *testing.T) {
+	outputFileName := "drift.yaml"
+	recordFile := `zone_name: example.com
+zone_id: "2"
+downloaded_at: 2013-06-01T00:00:00Z
+records:
+    - id: 372e67954025e0ba6aaa6d586b9e0b59
+      keep: false
+      name: example.com
+      type: A
+      content: 198.51.100.4
+      ttl: 120
+      proxied: false
+      modified_on: 2013-06-01T00:00:00Z
+`
+	err := os.WriteFile(outputFileName, []byte(recordFile), 0600)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(outputFileName)
+	err = withApp(t, []string{"cloudflare-utils", "dns-cleaner", "upload", "--dns-file", outputFileName})
+	if assert.Error(t, err, "Expected an error when the record was modified after download") {
+		assert.Contains(t, err.Error(), "1 records were changed in the zone after the DNS file was downloaded: A example.com (372e67954025e0ba6aaa6d586b9e0b59)")
+	}
+
+	err = withApp(t, []string{"cloudflare-utils", "dns-cleaner", "upload", "--dns-file", outputFileName, "--force"})
+	assert.NoError(t, err, "Expected no error when forcing the upload")
+}
+
+func Test_BuildDNSPlanDeleteMissing(t *testing.T) {
+	recordFile := &RecordFile{
+		ZoneID: "2",
+		Records: []DNSRecord{
+			{ID: "1", Keep: true, Type: "A", Name: "www.example.com", Content: "198.51.100.4", TTL: 1, Proxied: cloudflare.BoolPtr(true)},
+			// A record from a zone file of another provider, matched to live record 3 by its content.
+			{Keep: true, Type: "TXT", Name: "example.com", Content: "v=spf1 -all", TTL: 3600, Proxied: cloudflare.BoolPtr(false)},
+		},
+	}
+	live := testLiveRecords()
+	plan := buildDNSPlan(recordFile, live)
+	assert.Empty(t, plan.Changes, "Expected records missing from the file to be kept without --delete-missing")
+
+	plan.DeleteMissing(live)
+	toDelete := plan.LiveRecords(dnsDeleteAction)
+	if assert.Len(t, toDelete, 1, "Expected only the record missing from the file to be deleted") {
+		assert.Equal(t, "2", toDelete[0].ID)
+	}
+	var output bytes.Buffer
+	plan.Print(&output)
+	assert.Contains(t, output.String(), "  - delete A old.example.com (2)\n      content: \"198.51.100.9\"\n      ! not in the DNS file\n")
+	assert.Empty(t, plan.Diverged(), "Expected records missing from the file not to be treated as drift")
+}
@@ -3,72 +3,290 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"slices"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/cloudflare/cloudflare-go"
 	"github.com/urfave/cli/v3"
 )
 
 const (
-	confirmFlag = "confirm"
+	confirmFlag       = "confirm"
+	nameGlobFlag      = "name-glob"
+	nameRegexFlag     = "name-regex"
+	contentRegexFlag  = "content-regex"
+	createdBeforeFlag = "created-before"
+	createdAfterFlag  = "created-after"
 )
 
+// promptInput is where confirmation prompts are read from. It is replaced in tests.
+var promptInput io.Reader = os.Stdin
+
+// readPrompt calls read with promptInput and returns the context error as soon as ctx is done.
+// A read from stdin cannot be interrupted, so it is left blocked in the background and the command can still exit on Ctrl-C.
+func readPrompt(ctx context.Context, read func(io.Reader) (string, error)) (string, error) {
+	type result struct {
+		value string
+		err   error
+	}
+	input := promptInput
+	done := make(chan result, 1)
+	go func() {
+		value, err := read(input)
+		done <- result{value: value, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// scanPromptWord reads the first word of a line of input.
+func scanPromptWord(r io.Reader) (string, error) {
+	var word string
+	_, err := fmt.Fscanln(r, &word)
+	return word, err
+}
+
 // buildDNSPurgeCommand creates the dns-purge command.
 func buildDNSPurgeCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "dns-purge",
-		Usage: "Deletes all dns records.\nAPI Token Requirements: DNS:Edit",
-		Flags: []cli.Flag{
+		Usage: "Deletes all dns records, or only the records that match the filters.\nAPI Token Requirements: DNS:Edit",
+		Flags: slices.Concat([]cli.Flag{
 			&cli.BoolFlag{
 				Name:  confirmFlag,
 				Usage: "Auto confirm to delete records",
 				Value: false,
 			},
-		},
+			&cli.StringFlag{
+				Name:     nameGlobFlag,
+				Usage:    "Only delete records with a name that matches the glob, such as _acme-challenge.*",
+				Category: dnsFilterCategory,
+			},
+			&cli.StringFlag{
+				Name:     nameRegexFlag,
+				Usage:    "Only delete records with a name that matches the regular expression",
+				Category: dnsFilterCategory,
+			},
+			&cli.StringFlag{
+				Name:     contentRegexFlag,
+				Usage:    "Only delete records with content that matches the regular expression",
+				Category: dnsFilterCategory,
+			},
+			&cli.StringFlag{
+				Name:     createdBeforeFlag,
+				Usage:    "Only delete records created before this time. Either a date (2024-01-31), RFC 3339 timestamp or an age such as 90d",
+				Category: dnsFilterCategory,
+			},
+			&cli.StringFlag{
+				Name:     createdAfterFlag,
+				Usage:    "Only delete records created after this time. Either a date (2024-01-31), RFC 3339 timestamp or an age such as 90d",
+				Category: dnsFilterCategory,
+			},
+		}, dnsFilterFlags(), dnsBackupFlags()),
 		Action: DNSPurge,
 	}
 }
 
-// DNSPurge is a command to delete all dns records without downloading.
+// dnsPurgeFilter are the filters of dns-purge that the API does not support.
+type dnsPurgeFilter struct {
+	nameGlob      string
+	nameRegex     *regexp.Regexp
+	contentRegex  *regexp.Regexp
+	createdBefore time.Time
+	createdAfter  time.Time
+}
+
+// newDNSPurgeFilter builds the filter from the CLI flags.
+func newDNSPurgeFilter(c *cli.Command, now time.Time) (*dnsPurgeFilter, error) {
+	filter := &dnsPurgeFilter{nameGlob: strings.ToLower(c.String(nameGlobFlag))}
+	var err error
+	if filter.nameGlob != "" {
+		if _, err = path.Match(filter.nameGlob, ""); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", nameGlobFlag, err)
+		}
+	}
+	if value := c.String(nameRegexFlag); value != "" {
+		if filter.nameRegex, err = regexp.Compile(value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", nameRegexFlag, err)
+		}
+	}
+	if value := c.String(contentRegexFlag); value != "" {
+		if filter.contentRegex, err = regexp.Compile(value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", contentRegexFlag, err)
+		}
+	}
+	if filter.createdBefore, err = parseFilterTime(c.String(createdBeforeFlag), now); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", createdBeforeFlag, err)
+	}
+	if filter.createdAfter, err = parseFilterTime(c.String(createdAfterFlag), now); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", createdAfterFlag, err)
+	}
+	return filter, nil
+}
+
+// parseFilterTime parses a date, an RFC 3339 timestamp or an age relative to now.
+func parseFilterTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if age, err := ParseRelativeDuration(value); err == nil {
+		return now.Add(-age), nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date, timestamp or age", value)
+}
+
+// Match checks if the record matches every filter that is set.
+func (f *dnsPurgeFilter) Match(record cloudflare.DNSRecord) bool {
+	if f.nameGlob != "" {
+		if matched, _ := path.Match(f.nameGlob, strings.ToLower(record.Name)); !matched {
+			return false
+		}
+	}
+	if f.nameRegex != nil && !f.nameRegex.MatchString(record.Name) {
+		return false
+	}
+	if f.contentRegex != nil && !f.contentRegex.MatchString(record.Content) {
+		return false
+	}
+	if !f.createdBefore.IsZero() && !record.CreatedOn.Before(f.createdBefore) {
+		return false
+	}
+	if !f.createdAfter.IsZero() && !record.CreatedOn.After(f.createdAfter) {
+		return false
+	}
+	return true
+}
+
+// DNSPurge is a command to delete all dns records, or the records that match the filters, without downloading.
 func DNSPurge(ctx context.Context, c *cli.Command) error {
 	logger.Info("Starting DNS Purge")
 	if err := CheckAPITokenPermission(ctx, DNSWrite); err != nil {
 		return err
 	}
 
-	err := GetZoneID(ctx, c)
+	filter, err := newDNSPurgeFilter(c, time.Now())
 	if err != nil {
 		return err
 	}
 
-	records, _, err := APIClient.ListDNSRecords(ctx, zoneRC, cloudflare.ListDNSRecordsParams{})
+	err = GetZoneID(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	records, err := ListAllDNSRecords(ctx, zoneRC, dnsListParams(c))
 	if err != nil {
 		logger.WithError(err).Error("Error getting zone info with ID")
 		return err
 	}
-	if !c.Bool(confirmFlag) {
-		var confirmString string
-		fmt.Printf("About to remove %d records.\nContinue (y/n): ", len(records))
-		if _, err := fmt.Scanln(&confirmString); err != nil {
-			return err
-		}
-		if !strings.EqualFold(confirmString, "y") {
-			fmt.Println("Did not get `y` as input. Exiting")
-			return nil
+	var toDelete []cloudflare.DNSRecord
+	for _, record := range records {
+		if filter.Match(record) {
+			toDelete = append(toDelete, record)
 		}
 	}
-	if len(records) == 0 {
+	if len(toDelete) == 0 {
 		fmt.Println("No records to delete")
 		return nil
 	}
 
-	errors := RapidDNSDelete(zoneRC, records)
-	errorCount := len(errors)
+	printDNSPurgeTable(os.Stdout, toDelete)
+	if !c.Bool(confirmFlag) {
+		confirmed, err := confirmDNSPurge(ctx, c, len(toDelete))
+		if err != nil || !confirmed {
+			return err
+		}
+	}
+
+	if _, err := backupDNSRecords(c, zoneRC.Identifier, toDelete); err != nil {
+		return err
+	}
+
+	report := RapidDNSDelete(ctx, zoneRC, toDelete)
+	if canceled := report.Canceled(); canceled > 0 {
+		return fmt.Errorf("canceled after deleting %d of %d dns records: %w", report.Succeeded(), len(toDelete), ctx.Err())
+	}
+	errorCount := len(report.Failed())
 
 	if errorCount == 0 {
-		fmt.Printf("Successfully deleted all %d dns records\n", len(records))
+		fmt.Printf("Successfully deleted all %d dns records\n", len(toDelete))
 	} else {
 		fmt.Printf("Error deleting %d dns records.\nPlease review errors and reach out if you believe to be an error with the program\n", errorCount)
 	}
 	return nil
 }
+
+// printDNSPurgeTable prints the records that will be deleted.
+func printDNSPurgeTable(w io.Writer, records []cloudflare.DNSRecord) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "TYPE\tNAME\tCONTENT\tPROXIED\tCREATED")
+	for _, record := range records {
+		proxied := record.Proxied != nil && *record.Proxied
+		fmt.Fprintf(table, "%s\t%s\t%s\t%t\t%s\n", record.Type, record.Name, record.Content, proxied, record.CreatedOn.Format(time.DateOnly))
+	}
+	table.Flush()
+}
+
+// confirmDNSPurge asks the user to confirm deleting the records.
+// Deleting every record in the zone requires typing the zone name instead of `y`.
+func confirmDNSPurge(ctx context.Context, c *cli.Command, count int) (bool, error) {
+	if !dnsPurgeFiltered(c) {
+		zoneName := c.String(zoneNameFlag)
+		if zoneName == "" {
+			zone, err := APIClient.ZoneDetails(ctx, zoneRC.Identifier)
+			if err != nil {
+				return false, fmt.Errorf("error getting zone name to confirm: %w", err)
+			}
+			zoneName = zone.Name
+		}
+		fmt.Printf("About to remove ALL %d records in the zone.\nType the zone name (%s) to continue: ", count, zoneName)
+		confirmString, err := readPrompt(ctx, scanPromptWord)
+		if err != nil {
+			return false, err
+		}
+		if !strings.EqualFold(strings.TrimSuffix(confirmString, "."), zoneName) {
+			fmt.Println("Zone name did not match. Exiting")
+			return false, nil
+		}
+		return true, nil
+	}
+	fmt.Printf("About to remove %d records.\nContinue (y/n): ", count)
+	confirmString, err := readPrompt(ctx, scanPromptWord)
+	if err != nil {
+		return false, err
+	}
+	if !strings.EqualFold(confirmString, "y") {
+		fmt.Println("Did not get `y` as input. Exiting")
+		return false, nil
+	}
+	return true, nil
+}
+
+// dnsPurgeFiltered checks if any filter is set, so not every record in the zone is deleted.
+func dnsPurgeFiltered(c *cli.Command) bool {
+	for _, flag := range []string{
+		recordTypeFlag, recordNameFlag, recordContentFlag, recordCommentFlag, recordTagFlag, recordProxiedFlag,
+		nameGlobFlag, nameRegexFlag, contentRegexFlag, createdBeforeFlag, createdAfterFlag,
+	} {
+		if c.IsSet(flag) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/cloudflare/cloudflare-go"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -10,3 +17,91 @@ func Test_DNSPurge(t *testing.T) {
 	err := withApp(t, []string{"cloudflare-utils", "dns-purge", "--zone-name", "2", "--confirm"})
 	assert.NoError(t, err, "Expected no error when running the app with dns-purge command")
 }
+
+func Test_DNSPurgeFilter(t *testing.T) {
+	created := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	record := cloudflare.DNSRecord{Type: "TXT", Name: "_acme-challenge.www.example.com", Content: "token-value", CreatedOn: created}
+	testCases := []struct {
+		name   string
+		filter dnsPurgeFilter
+		match  bool
+	}{
+		{name: "No filters", match: true},
+		{name: "Name glob", filter: dnsPurgeFilter{nameGlob: "_acme-challenge.*"}, match: true},
+		{name: "Name glob miss", filter: dnsPurgeFilter{nameGlob: "_acme-challenge.example.com"}},
+		{name: "Name regex", filter: dnsPurgeFilter{nameRegex: regexp.MustCompile(`^_acme-challenge\.`)}, match: true},
+		{name: "Content regex miss", filter: dnsPurgeFilter{contentRegex: regexp.MustCompile(`^v=spf1`)}},
+		{name: "Created before", filter: dnsPurgeFilter{createdBefore: created.Add(time.Hour)}, match: true},
+		{name: "Created after miss", filter: dnsPurgeFilter{createdAfter: created.Add(time.Hour)}},
+		{name: "Window", filter: dnsPurgeFilter{createdAfter: created.Add(-time.Hour), createdBefore: created.Add(time.Hour)}, match: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.match, tc.filter.Match(record))
+		})
+	}
+}
+
+func Test_ParseFilterTime(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	parsed, err := parseFilterTime("2024-01-31", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), parsed)
+
+	parsed, err = parseFilterTime("2024-01-31T10:00:00Z", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC), parsed)
+
+	parsed, err = parseFilterTime("2d", now)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(-48*time.Hour), parsed)
+
+	_, err = parseFilterTime("last week", now)
+	assert.EqualError(t, err, `"last week" is not a date, timestamp or age`)
+}
+
+func Test_DNSPurgeTable(t *testing.T) {
+	var output bytes.Buffer
+	printDNSPurgeTable(&output, []cloudflare.DNSRecord{
+		{Type: "TXT", Name: "_acme-challenge.example.com", Content: "token", Proxied: cloudflare.BoolPtr(false), CreatedOn: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+	})
+	assert.Equal(t, "TYPE  NAME                         CONTENT  PROXIED  CREATED\nTXT   _acme-challenge.example.com  token    false    2024-01-15\n", output.String())
+}
+
+func Test_DNSPurgeConfirm(t *testing.T) {
+	defaultInput := promptInput
+	defer func() { promptInput = defaultInput }()
+
+	promptInput = strings.NewReader("other.com\n")
+	err := withApp(t, []string{"cloudflare-utils", "dns-purge", "--zone-name", "example.com"})
+	assert.NoError(t, err, "Expected no error when the zone name does not match")
+
+	promptInput = strings.NewReader("example.com\n")
+	err = withApp(t, []string{"cloudflare-utils", "dns-purge", "--zone-name", "example.com"})
+	assert.NoError(t, err, "Expected no error when typing the zone name to purge every record")
+
+	promptInput = strings.NewReader("y\n")
+	err = withApp(t, []string{"cloudflare-utils", "dns-purge", "--zone-name", "example.com", "--name-glob", "*.com"})
+	assert.NoError(t, err, "Expected no error when confirming a filtered purge")
+
+	err = withApp(t, []string{"cloudflare-utils", "dns-purge", "--zone-name", "example.com", "--name-regex", "("})
+	assert.ErrorContains(t, err, "invalid name-regex")
+}
+
+func Test_ReadPrompt(t *testing.T) {
+	defaultInput := promptInput
+	defer func() { promptInput = defaultInput }()
+
+	promptInput = strings.NewReader("example.com\n")
+	value, err := readPrompt(t.Context(), scanPromptWord)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", value)
+
+	// Nothing is ever written to the pipe, so only the canceled context can end the read.
+	blocked, _ := io.Pipe()
+	promptInput = blocked
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err = readPrompt(ctx, scanPromptWord)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"gopkg.in/yaml.v3"
+)
+
+// DNSRuleFile is the struct of the YAML rules file used to decide which records to keep when downloading.
+type DNSRuleFile struct {
+	Rules []*DNSRule `yaml:"rules"`
+}
+
+// DNSRule sets the keep value of every record that matches all of its conditions.
+type DNSRule struct {
+	Name  string       `yaml:"name"`
+	Keep  *bool        `yaml:"keep"`
+	Match DNSRuleMatch `yaml:"match"`
+
+	nameRegex    *regexp.Regexp
+	commentRegex *regexp.Regexp
+	contentCIDRs []netip.Prefix
+	olderThan    time.Duration
+	newerThan    time.Duration
+}
+
+// DNSRuleMatch are the conditions of a rule. Conditions that are not set match every record.
+type DNSRuleMatch struct {
+	// Name is a regular expression matched against the full record name.
+	Name string `yaml:"name,omitempty"`
+	// Types is a list of record types. The record must be one of them.
+	Types []string `yaml:"types,omitempty"`
+	// ContentCIDRs is a list of CIDRs. The record content must be an IP address in one of them.
+	ContentCIDRs []string `yaml:"content_cidrs,omitempty"`
+	// ContentHosts is a list of hostname globs such as `*.pages.dev`. The record content must match one of them.
+	ContentHosts []string `yaml:"content_hosts,omitempty"`
+	// Comment is a regular expression matched against the record comment.
+	Comment string `yaml:"comment,omitempty"`
+	// Tags is a list of tags. The record must have at least one of them.
+	Tags []string `yaml:"tags,omitempty"`
+	// OlderThan matches records created longer ago than the duration, for example `90d`.
+	OlderThan string `yaml:"older_than,omitempty"`
+	// NewerThan matches records created more recently than the duration.
+	NewerThan string `yaml:"newer_than,omitempty"`
+}
+
+// loadDNSRules reads and validates a rules file.
+func loadDNSRules(filePath string) (*DNSRuleFile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rules file: %w", err)
+	}
+	ruleFile := &DNSRuleFile{}
+	if err := yaml.Unmarshal(data, ruleFile); err != nil {
+		return nil, fmt.Errorf("error parsing rules file: %w", err)
+	}
+	if len(ruleFile.Rules) == 0 {
+		return nil, errors.New("rules file has no rules")
+	}
+	for i, rule := range ruleFile.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+	}
+	return ruleFile, nil
+}
+
+func (rule *DNSRule) compile() error {
+	if rule.Keep == nil {
+		return errors.New("keep must be set")
+	}
+	var err error
+	if rule.Match.Name != "" {
+		if rule.nameRegex, err = regexp.Compile(rule.Match.Name); err != nil {
+			return fmt.Errorf("invalid name regex: %w", err)
+		}
+	}
+	if rule.Match.Comment != "" {
+		if rule.commentRegex, err = regexp.Compile(rule.Match.Comment); err != nil {
+			return fmt.Errorf("invalid comment regex: %w", err)
+		}
+	}
+	for _, cidr := range rule.Match.ContentCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid content CIDR: %w", err)
+		}
+		rule.contentCIDRs = append(rule.contentCIDRs, prefix)
+	}
+	for _, host := range rule.Match.ContentHosts {
+		if _, err := path.Match(host, ""); err != nil {
+			return fmt.Errorf("invalid content host pattern %q: %w", host, err)
+		}
+	}
+	if rule.Match.OlderThan != "" {
+		if rule.olderThan, err = ParseRelativeDuration(rule.Match.OlderThan); err != nil {
+			return fmt.Errorf("invalid older_than: %w", err)
+		}
+	}
+	if rule.Match.NewerThan != "" {
+		if rule.newerThan, err = ParseRelativeDuration(rule.Match.NewerThan); err != nil {
+			return fmt.Errorf("invalid newer_than: %w", err)
+		}
+	}
+	return nil
+}
+
+// Evaluate returns the first rule that matches the record.
+func (f *DNSRuleFile) Evaluate(record cloudflare.DNSRecord, now time.Time) (*DNSRule, bool) {
+	if f == nil {
+		return nil, false
+	}
+	for _, rule := range f.Rules {
+		if rule.matches(record, now) {
+			return rule, true
+		}
+	}
+	return nil, false
+}
+
+func (rule *DNSRule) matches(record cloudflare.DNSRecord, now time.Time) bool {
+	if rule.nameRegex != nil && !rule.nameRegex.MatchString(record.Name) {
+		return false
+	}
+	if len(rule.Match.Types) > 0 && !slices.ContainsFunc(rule.Match.Types, func(recordType string) bool {
+		return strings.EqualFold(recordType, record.Type)
+	}) {
+		return false
+	}
+	if len(rule.contentCIDRs) > 0 {
+		address, err := netip.ParseAddr(record.Content)
+		if err != nil || !slices.ContainsFunc(rule.contentCIDRs, func(prefix netip.Prefix) bool { return prefix.Contains(address) }) {
+			return false
+		}
+	}
+	if len(rule.Match.ContentHosts) > 0 {
+		content := strings.TrimSuffix(strings.ToLower(record.Content), ".")
+		if !slices.ContainsFunc(rule.Match.ContentHosts, func(pattern string) bool {
+			matched, _ := path.Match(strings.ToLower(pattern), content)
+			return matched
+		}) {
+			return false
+		}
+	}
+	if rule.commentRegex != nil && !rule.commentRegex.MatchString(record.Comment) {
+		return false
+	}
+	if len(rule.Match.Tags) > 0 && !slices.ContainsFunc(rule.Match.Tags, func(tag string) bool { return slices.Contains(record.Tags, tag) }) {
+		return false
+	}
+	if rule.olderThan > 0 && !record.CreatedOn.Before(now.Add(-rule.olderThan)) {
+		return false
+	}
+	if rule.newerThan > 0 && !record.CreatedOn.After(now.Add(-rule.newerThan)) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDNSRules = `rules:
+  - name: keep-apex
+    keep: true
+    match:
+      name: '^example\.com$'
+  - name: internal-addresses
+    keep: false
+    match:
+      types: [a, AAAA]
+      content_cidrs: [10.0.0.0/8, "fd00::/8"]
+  - name: old-previews
+    keep: false
+    match:
+      content_hosts: ["*.pages.dev"]
+      older_than: 90d
+  - name: temporary
+    keep: false
+    match:
+      comment: '(?i)temp'
+      tags: [env:preview]
+`
+
+func writeTestDNSRules(t *testing.T, rules string) string {
+	t.Helper()
+	fileName := "dns-rules.yml"
+	require.NoError(t, os.WriteFile(fileName, []byte(rules), 0600))
+	t.Cleanup(func() { os.Remove(fileName) })
+	return fileName
+}
+
+func Test_DNSRulesEvaluate(t *testing.T) {
+	rules, err := loadDNSRules(writeTestDNSRules(t, testDNSRules))
+	require.NoError(t, err, "Expected no error loading the rules file")
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name   string
+		record cloudflare.DNSRecord
+		rule   string
+	}{
+		{name: "Apex", record: cloudflare.DNSRecord{Name: "example.com", Type: "A", Content: "10.0.0.1"}, rule: "keep-apex"},
+		{name: "Internal IPv4", record: cloudflare.DNSRecord{Name: "db.example.com", Type: "A", Content: "10.1.2.3"}, rule: "internal-addresses"},
+		{name: "Internal IPv6", record: cloudflare.DNSRecord{Name: "db.example.com", Type: "AAAA", Content: "fd00::1"}, rule: "internal-addresses"},
+		{name: "Public IPv4", record: cloudflare.DNSRecord{Name: "www.example.com", Type: "A", Content: "198.51.100.4"}},
+		{name: "Old preview", record: cloudflare.DNSRecord{Name: "pr-1.example.com", Type: "CNAME", Content: "pr-1.site.pages.dev", CreatedOn: now.AddDate(0, -6, 0)}, rule: "old-previews"},
+		{name: "New preview", record: cloudflare.DNSRecord{Name: "pr-2.example.com", Type: "CNAME", Content: "pr-2.site.pages.dev", CreatedOn: now.AddDate(0, 0, -1)}},
+		{name: "Temporary", record: cloudflare.DNSRecord{Name: "test.example.com", Type: "TXT", Content: "test", Comment: "Temporary record", Tags: []string{"env:preview"}}, rule: "temporary"},
+		{name: "Temporary without tag", record: cloudflare.DNSRecord{Name: "test.example.com", Type: "TXT", Content: "test", Comment: "Temporary record"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, matched := rules.Evaluate(tc.record, now)
+			if tc.rule == "" {
+				assert.False(t, matched, "Expected no rule to match")
+				return
+			}
+			if assert.True(t, matched, "Expected a rule to match") {
+				assert.Equal(t, tc.rule, rule.Name)
+			}
+		})
+	}
+}
+
+func Test_DNSRulesErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		rules  string
+		errMsg string
+	}{
+		{name: "No rules", rules: "rules: []\n", errMsg: "rules file has no rules"},
+		{name: "Missing keep", rules: "rules:\n  - name: a\n    match:\n      types: [A]\n", errMsg: "rule a: keep must be set"},
+		{name: "Bad regex", rules: "rules:\n  - keep: false\n    match:\n      name: '('\n", errMsg: "rule rule-1: invalid name regex: error parsing regexp: missing closing ): `(`"},
+		{name: "Bad CIDR", rules: "rules:\n  - name: a\n    keep: false\n    match:\n      content_cidrs: [10.0.0.0/33]\n", errMsg: "rule a: invalid content CIDR: netip.ParsePrefix(\"10.0.0.0/33\")"},
+		{name: "Bad age", rules: "rules:\n  - name: a\n    keep: false\n    match:\n      older_than: soon\n", errMsg: "rule a: invalid older_than: invalid duration: soon"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := loadDNSRules(writeTestDNSRules(t, tc.rules))
+			assert.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+}
+
+func Test_DNSCleanerDownloadRules(t *testing.T) {
+	rulesFile := writeTestDNSRules(t, "rules:\n  - name: remove-test-net\n    keep: false\n    match:\n      content_cidrs: [198.51.100.0/24]\n")
+	outputFileName := "rules.yaml"
+	err := withApp(t, []string{"cloudflare-utils", "dns-cleaner", "download", "--zone-id", "2", "--dns-file", outputFileName, "--rules-file", rulesFile})
+	require.NoError(t, err, "Expected no error when downloading records with a rules file")
+	defer os.Remove(outputFileName)
+
+	data, err := os.ReadFile(outputFileName)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "keep: false")
+	assert.Contains(t, string(data), "rule: remove-test-net")
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Cyb3r-Jak3/common/v5"
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/urfave/cli/v3"
+)
+
+const exportOutputFlag = "output"
+
+// listExport is the JSON format of an exported list.
+type listExport struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Kind        string           `json:"kind"`
+	ExportedAt  time.Time        `json:"exported_at"`
+	Items       []listExportItem `json:"items"`
+}
+
+// listExportItem is a single item of an exported list. Value is written the same way as in a sync-list source.
+type listExportItem struct {
+	Value      string     `json:"value"`
+	Comment    string     `json:"comment"`
+	CreatedOn  *time.Time `json:"created_on"`
+	ModifiedOn *time.Time `json:"modified_on"`
+}
+
+func buildListExportCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "list-export",
+		Usage:  "Export the items of a Cloudflare List to a file, such as a backup before syncing\nAPI Token Requirements: Account Filter Lists:Read",
+		Action: ListExport,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "list-name",
+				Usage: "Name of the list to export",
+			},
+			&cli.StringFlag{
+				Name:  "list-id",
+				Usage: "ID of the list to export. If both list-name and list-id are provided, list-id will be used.",
+			},
+			&cli.StringFlag{
+				Name: sourceFormatFlag,
+				Usage: fmt.Sprintf("Format of the export. Can be %s, %s or %s. A %s export can be synced again with sync-list and keeps the comments.",
+					textSourceFormat, jsonSourceFormat, csvSourceFormat, textSourceFormat),
+				Value: textSourceFormat,
+				Action: func(_ context.Context, _ *cli.Command, s string) error {
+					if !common.StringSearch(s, validSourceFormats) {
+						return fmt.Errorf("invalid format: %s. Valid formats are: %s", s, strings.Join(validSourceFormats, ", "))
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:    exportOutputFlag,
+				Aliases: []string{"o"},
+				Usage:   "File to write the export to. Defaults to stdout",
+			},
+		},
+	}
+}
+
+// ListExport writes the items of a list to stdout or a file.
+func ListExport(ctx context.Context, c *cli.Command) error {
+	if accountRC == nil {
+		return fmt.Errorf("account ID must be set for this command")
+	}
+	listID, listName := c.String("list-id"), c.String("list-name")
+	if listID == "" && listName == "" {
+		return fmt.Errorf("either --list-id or --list-name must be provided")
+	}
+	list, err := findCloudflareList(ctx, listID, listName)
+	if err != nil {
+		return err
+	}
+	items, err := APIClient.ListListItems(ctx, accountRC, cloudflare.ListListItemsParams{ID: list.ID})
+	if err != nil {
+		return fmt.Errorf("error getting list items: %w", err)
+	}
+	logger.Infof("Exporting %d items from list %s", len(items), list.Name)
+
+	var w io.Writer = os.Stdout
+	if output := c.String(exportOutputFlag); output != "" {
+		file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("error creating export file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := writeListExport(w, c.String(sourceFormatFlag), list, items, time.Now().UTC()); err != nil {
+		return fmt.Errorf("error writing export: %w", err)
+	}
+	if output := c.String(exportOutputFlag); output != "" {
+		fmt.Printf("Exported %d items from list %s to %s\n", len(items), list.Name, output)
+	}
+	return nil
+}
+
+// findCloudflareList returns the list with the ID, or the name if no ID is given. Unlike getCloudflareList, it never creates the list.
+func findCloudflareList(ctx context.Context, listID, listName string) (cloudflare.List, error) {
+	lists, err := APIClient.ListLists(ctx, accountRC, cloudflare.ListListsParams{})
+	if err != nil {
+		return cloudflare.List{}, fmt.Errorf("error fetching lists: %w", err)
+	}
+	for _, list := range lists {
+		if (listID != "" && list.ID == listID) || (listID == "" && list.Name == listName) {
+			return list, nil
+		}
+	}
+	if listID != "" {
+		return cloudflare.List{}, fmt.Errorf("no list found with ID %s", listID)
+	}
+	return cloudflare.List{}, fmt.Errorf("no list found with name %s", listName)
+}
+
+// writeListExport writes the items of a list in the format.
+// Every format writes the value of an item the same way as a sync-list source, so an export can be used as a source.
+func writeListExport(w io.Writer, format string, list cloudflare.List, items []cloudflare.ListItem, exportedAt time.Time) error {
+	exportItems := make([]listExportItem, 0, len(items))
+	for _, item := range items {
+		exportItems = append(exportItems, listExportItem{
+			Value:      listItemSourceValue(listItemRequest(item)),
+			Comment:    item.Comment,
+			CreatedOn:  item.CreatedOn,
+			ModifiedOn: item.ModifiedOn,
+		})
+	}
+
+	switch format {
+	case jsonSourceFormat:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(listExport{
+			ID:          list.ID,
+			Name:        list.Name,
+			Description: list.Description,
+			Kind:        list.Kind,
+			ExportedAt:  exportedAt,
+			Items:       exportItems,
+		})
+	case csvSourceFormat:
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"value", "comment", "created_on", "modified_on"}); err != nil {
+			return err
+		}
+		for _, item := range exportItems {
+			if err := writer.Write([]string{item.Value, item.Comment, formatExportTime(item.CreatedOn), formatExportTime(item.ModifiedOn)}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case textSourceFormat:
+		// The header and the comments are written as # comments, which sync-list reads back as the item comments.
+		if _, err := fmt.Fprintf(w, "# Export of %s list %s (%s) on %s\n", list.Kind, list.Name, list.ID, exportedAt.Format(time.RFC3339)); err != nil {
+			return err
+		}
+		for _, item := range exportItems {
+			line := item.Value
+			if comment := strings.TrimSpace(item.Comment); comment != "" {
+				line += " # " + comment
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return errors.New("invalid format: " + format)
+	}
+}
+
+// formatExportTime formats an item timestamp for a CSV export.
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
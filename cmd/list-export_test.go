@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WriteListExport(t *testing.T) {
+	created := time.Date(2020, 1, 1, 8, 0, 0, 0, time.UTC)
+	statusCode := 308
+	list := cloudflare.List{ID: "5e8cd1c2a1f94d8fb0b2d5c1f3a4e6b7", Name: "test-redirects", Kind: cloudflare.ListTypeRedirect}
+	items := []cloudflare.ListItem{
+		{Redirect: &cloudflare.Redirect{SourceUrl: "example.com/a", TargetUrl: "https://example.com/b?x=1,2", StatusCode: &statusCode, SubpathMatching: cloudflare.BoolPtr(true)}, Comment: "Moved", CreatedOn: &created, ModifiedOn: &created},
+		{Redirect: &cloudflare.Redirect{SourceUrl: "example.com/c", TargetUrl: "https://example.com/d"}},
+	}
+
+	var text bytes.Buffer
+	assert.NoError(t, writeListExport(&text, textSourceFormat, list, items, created))
+	assert.Equal(t, "# Export of redirect list test-redirects (5e8cd1c2a1f94d8fb0b2d5c1f3a4e6b7) on 2020-01-01T08:00:00Z\n"+
+		`example.com/a,"https://example.com/b?x=1,2",308,,,true, # Moved`+"\n"+
+		"example.com/c,https://example.com/d,,,,,\n", text.String())
+
+	// A text export has to parse back into the same items and comments.
+	parsed, err := parseListItems(splitSourceLines(text.String()), syncListOptions{Kind: cloudflare.ListTypeRedirect})
+	if assert.NoError(t, err) && assert.Len(t, parsed, 2) {
+		assert.Equal(t, listItemKey(listItemRequest(items[0])), listItemKey(parsed[0]))
+		assert.Equal(t, "Moved", parsed[0].Comment)
+		assert.Equal(t, listItemKey(listItemRequest(items[1])), listItemKey(parsed[1]))
+	}
+
+	var csvExport bytes.Buffer
+	assert.NoError(t, writeListExport(&csvExport, csvSourceFormat, list, items[:1], created))
+	assert.Equal(t, "value,comment,created_on,modified_on\n"+
+		`"example.com/a,""https://example.com/b?x=1,2"",308,,,true,",Moved,2020-01-01T08:00:00Z,2020-01-01T08:00:00Z`+"\n", csvExport.String())
+
+	var jsonExport bytes.Buffer
+	assert.NoError(t, writeListExport(&jsonExport, jsonSourceFormat, list, items[:1], created))
+	var decoded listExport
+	if assert.NoError(t, json.Unmarshal(jsonExport.Bytes(), &decoded)) {
+		assert.Equal(t, "test-redirects", decoded.Name)
+		assert.Equal(t, created, decoded.ExportedAt)
+		assert.Equal(t, "Moved", decoded.Items[0].Comment)
+	}
+}
+
+func Test_ListExport(t *testing.T) {
+	dir := t.TempDir()
+	for _, format := range []string{textSourceFormat, csvSourceFormat, jsonSourceFormat} {
+		output := filepath.Join(dir, "test-list."+format)
+		err := withApp(t, []string{"cloudflare-utils", "list-export", "--list-name", "test-list", "--format", format, "--output", output})
+		if assert.NoError(t, err, format) {
+			data, err := os.ReadFile(output)
+			assert.NoError(t, err)
+			assert.Contains(t, string(data), "198.51.100.0/24", format)
+		}
+	}
+
+	err := withApp(t, []string{"cloudflare-utils", "list-export", "--list-id", "5e8cd1c2a1f94d8fb0b2d5c1f3a4e6b7"})
+	assert.NoError(t, err, "Expected no error exporting a list by ID to stdout")
+
+	err = withApp(t, []string{"cloudflare-utils", "list-export", "--list-name", "missing-list"})
+	assert.EqualError(t, err, "no list found with name missing-list")
+
+	err = withApp(t, []string{"cloudflare-utils", "list-export"})
+	assert.EqualError(t, err, "either --list-id or --list-name must be provided")
+}
+
+func Test_SyncList_FromExport(t *testing.T) {
+	fileName := "test-list-export.txt"
+	err := withApp(t, []string{"cloudflare-utils", "list-export", "--list-name", "test-list", "--output", fileName})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.Remove(fileName)
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--mode", "diff", "--max-removals", "0", "--source", "file://" + fileName})
+	assert.NoError(t, err, "Expected an export of the list to sync without changes")
+
+	csvName := "test-list-export.csv"
+	err = withApp(t, []string{"cloudflare-utils", "list-export", "--list-name", "test-list", "--format", "csv", "--output", csvName})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.Remove(csvName)
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--format", "csv", "--csv-column", "value", "--dry-run", "--source", "file://" + csvName})
+	assert.NoError(t, err, "Expected a CSV export to be usable as a source")
+}
+
+func Test_SyncList_FromAbsoluteExport(t *testing.T) {
+	backup, err := filepath.Abs(filepath.Join(t.TempDir(), "backups", "test-list.txt"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Dir(backup), 0700))
+	err = withApp(t, []string{"cloudflare-utils", "list-export", "--list-name", "test-list", "--output", backup})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	source := "file://" + filepath.ToSlash(backup)
+	assert.True(t, strings.HasPrefix(source, "file:///"), "Expected an absolute file URL")
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--mode", "diff", "--max-removals", "0", "--source", source})
+	assert.NoError(t, err, "Expected an export written to an absolute path to sync without changes")
+}
+
+func Test_SourceFilePath(t *testing.T) {
+	testCases := map[string]string{
+		"file://list.txt":              "list.txt",
+		"file://backups/2024/list.txt": "backups/2024/list.txt",
+		"file:///var/backups/list.txt": "/var/backups/list.txt",
+	}
+	for source, expected := range testCases {
+		sourceURL, err := url.Parse(source)
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected, sourceFilePath(sourceURL), source)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	branchRegexFlag   = "branch-regex"
+	excludeBranchFlag = "exclude-branch"
+	liveBranchesFlag  = "live-branches"
+
+	pagesBranchCategory = "Branches"
+)
+
+// pagesBranchFlags are the flags of prune-deployments that select deployments by branch.
+func pagesBranchFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     branchNameFlag,
+			Aliases:  []string{"b"},
+			Usage:    "Branch to delete. Can be a glob such as dependabot/*, where * also matches /. Can be set multiple times",
+			Sources:  cli.EnvVars("CF_PAGES_BRANCH"),
+			Category: pagesBranchCategory,
+		},
+		&cli.StringSliceFlag{
+			Name:     branchRegexFlag,
+			Usage:    "Regular expression matched against the full branch name. Can be set multiple times",
+			Category: pagesBranchCategory,
+		},
+		&cli.StringSliceFlag{
+			Name:     excludeBranchFlag,
+			Usage:    "Branch to never delete, even if it is selected. Can be a glob and can be set multiple times",
+			Category: pagesBranchCategory,
+		},
+		&cli.StringFlag{
+			Name: liveBranchesFlag,
+			Usage: "File with the branches that still exist in the git remote, one per line, or - to read stdin. " +
+				"Deployments of every other branch are deleted. Accepts the output of git branch -r and git ls-remote --heads",
+			Category: pagesBranchCategory,
+		},
+	}
+}
+
+// deploymentBranchFilter selects deployments by the name of their branch.
+type deploymentBranchFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	// live are the branches that exist in the git remote. A nil map means the branches were not given.
+	live map[string]bool
+}
+
+// newDeploymentBranchFilter builds the branch filter from the CLI flags.
+func newDeploymentBranchFilter(ctx context.Context, c *cli.Command) (*deploymentBranchFilter, error) {
+	filter := &deploymentBranchFilter{}
+	for _, pattern := range c.StringSlice(branchNameFlag) {
+		filter.include = append(filter.include, branchGlob(pattern))
+	}
+	for _, pattern := range c.StringSlice(branchRegexFlag) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", branchRegexFlag, err)
+		}
+		filter.include = append(filter.include, re)
+	}
+	for _, pattern := range c.StringSlice(excludeBranchFlag) {
+		filter.exclude = append(filter.exclude, branchGlob(pattern))
+	}
+	if source := c.String(liveBranchesFlag); source != "" {
+		var err error
+		if filter.live, err = readLiveBranches(ctx, source); err != nil {
+			return nil, err
+		}
+	}
+	if len(filter.exclude) > 0 && filter.IsZero() {
+		return nil, fmt.Errorf("--%s needs --%s, --%s or --%s", excludeBranchFlag, branchNameFlag, branchRegexFlag, liveBranchesFlag)
+	}
+	return filter, nil
+}
+
+// branchGlob compiles a glob into a regular expression that matches the full branch name.
+// Unlike path.Match, * also matches /, because branches such as dependabot/npm_and_yarn/lodash-4.17.21 have more than one.
+func branchGlob(pattern string) *regexp.Regexp {
+	var expression strings.Builder
+	expression.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expression.WriteString(".*")
+		case '?':
+			expression.WriteString(".")
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expression.WriteString("$")
+	return regexp.MustCompile(expression.String())
+}
+
+// readLiveBranches reads the branches in the git remote from a file, or stdin if the source is -.
+func readLiveBranches(ctx context.Context, source string) (map[string]bool, error) {
+	var data string
+	var err error
+	if source == "-" {
+		data, err = readPrompt(ctx, func(r io.Reader) (string, error) {
+			all, err := io.ReadAll(r)
+			return string(all), err
+		})
+	} else {
+		var file []byte
+		file, err = os.ReadFile(source)
+		data = string(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading live branches: %w", err)
+	}
+	live := make(map[string]bool)
+	for _, entry := range splitSourceLines(data) {
+		// Skip the HEAD line of git branch -r, such as origin/HEAD -> origin/main.
+		if strings.Contains(entry.Value, " -> ") {
+			continue
+		}
+		live[parseLiveBranch(entry.Value)] = true
+	}
+	if len(live) == 0 {
+		return nil, errors.New("no live branches found. Refusing to delete the deployments of every branch")
+	}
+	return live, nil
+}
+
+// parseLiveBranch returns the branch name of a line of git branch -r, git ls-remote --heads or a plain list of branches.
+// Branches from git branch -r are expected to be from the origin remote.
+func parseLiveBranch(line string) string {
+	fields := strings.Fields(line)
+	// git ls-remote --heads prints the commit before the ref.
+	branch := fields[len(fields)-1]
+	if ref, ok := strings.CutPrefix(branch, "refs/heads/"); ok {
+		return ref
+	}
+	return strings.TrimPrefix(branch, "origin/")
+}
+
+// IsZero checks if the filter selects no branches.
+func (f *deploymentBranchFilter) IsZero() bool {
+	return len(f.include) == 0 && f.live == nil
+}
+
+// Match checks if the deployments of a branch should be deleted.
+// A branch matches if it matches any of the included patterns, is not a live branch and does not match any excluded pattern.
+func (f *deploymentBranchFilter) Match(branch string) bool {
+	for _, re := range f.exclude {
+		if re.MatchString(branch) {
+			return false
+		}
+	}
+	if f.live != nil && f.live[branch] {
+		return false
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(branch) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BranchGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		branch  string
+		match   bool
+	}{
+		{pattern: "main", branch: "main", match: true},
+		{pattern: "main", branch: "main-2", match: false},
+		{pattern: "dependabot/*", branch: "dependabot/npm_and_yarn/lodash-4.17.21", match: true},
+		{pattern: "dependabot/*", branch: "feature/dependabot", match: false},
+		{pattern: "release-?", branch: "release-1", match: true},
+		{pattern: "release-?", branch: "release-10", match: false},
+		{pattern: "fix.1", branch: "fix-1", match: false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.match, branchGlob(tc.pattern).MatchString(tc.branch), "%s matching %s", tc.pattern, tc.branch)
+	}
+}
+
+func Test_DeploymentBranchFilter(t *testing.T) {
+	filter := &deploymentBranchFilter{
+		include: []*regexp.Regexp{branchGlob("dependabot/*"), branchGlob("renovate/*"), regexp.MustCompile(`^feature-\d+$`)},
+		exclude: []*regexp.Regexp{branchGlob("renovate/keep-*")},
+	}
+	assert.True(t, filter.Match("dependabot/go_modules/golang.org/x/net-0.38.0"))
+	assert.True(t, filter.Match("renovate/major-react"))
+	assert.True(t, filter.Match("feature-12"))
+	assert.False(t, filter.Match("renovate/keep-pinned"))
+	assert.False(t, filter.Match("feature-12-fix"))
+	assert.False(t, filter.Match("main"))
+
+	live := &deploymentBranchFilter{
+		exclude: []*regexp.Regexp{branchGlob("main")},
+		live:    map[string]bool{"develop": true, "feature/login": true},
+	}
+	assert.False(t, live.IsZero())
+	assert.False(t, live.Match("develop"))
+	assert.False(t, live.Match("main"))
+	assert.True(t, live.Match("feature/deleted"))
+}
+
+func Test_ParseLiveBranch(t *testing.T) {
+	testCases := map[string]string{
+		"main":                             "main",
+		"  origin/feature/login":           "feature/login",
+		"3f2a9c1d\trefs/heads/release/1.2": "release/1.2",
+	}
+	for line, expected := range testCases {
+		assert.Equal(t, expected, parseLiveBranch(line), line)
+	}
+}
+
+func Test_ReadLiveBranches(t *testing.T) {
+	logger = logrus.New()
+	defaultInput := promptInput
+	defer func() { promptInput = defaultInput }()
+
+	promptInput = strings.NewReader("  origin/HEAD -> origin/main\n  origin/main\n  origin/feature/login\n")
+	live, err := readLiveBranches(t.Context(), "-")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"main": true, "feature/login": true}, live)
+
+	assert.NoError(t, os.WriteFile("live-branches.txt", []byte("# Branches\nmain\ndevelop\n"), 0600))
+	defer os.Remove("live-branches.txt")
+	live, err = readLiveBranches(t.Context(), "live-branches.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"main": true, "develop": true}, live)
+
+	promptInput = strings.NewReader("\n")
+	_, err = readLiveBranches(t.Context(), "-")
+	assert.EqualError(t, err, "no live branches found. Refusing to delete the deployments of every branch")
+
+	_, err = readLiveBranches(t.Context(), "missing-branches.txt")
+	assert.ErrorContains(t, err, "error reading live branches")
+
+	// A canceled read of stdin returns instead of waiting for input that never comes.
+	blocked, _ := io.Pipe()
+	promptInput = blocked
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err = readLiveBranches(ctx, "-")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_PruneBranchDeployments(t *testing.T) {
+	logger = logrus.New()
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	deployments := testDeploymentsWithoutMetadata(now)
+	filter := &deploymentBranchFilter{live: map[string]bool{"main": true}}
+	toDelete := PruneBranchDeployments(filter, pruneDeploymentOptions{SelectedDeployments: deployments})
+	assert.Equal(t, []string{"deleted"}, deploymentIDs(toDelete))
+}
+
+// testDeploymentsWithoutMetadata returns deployments of a live and a deleted branch and a deployment without a branch.
+func testDeploymentsWithoutMetadata(now time.Time) []cloudflare.PagesProjectDeployment {
+	unknown := testDeployment("unknown", "", "preview", 3, now)
+	unknown.DeploymentTrigger.Metadata = nil
+	return []cloudflare.PagesProjectDeployment{
+		testDeployment("live", "main", "production", 1, now),
+		testDeployment("deleted", "feature/old", "preview", 2, now),
+		unknown,
+	}
+}
+
+func Test_PruneDeployments_BranchPatterns(t *testing.T) {
+	logger = logrus.New()
+	defaultInput := promptInput
+	defer func() { promptInput = defaultInput }()
+
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	unknown := testDeployment("unknown", "", "preview", 90, now)
+	unknown.DeploymentTrigger.Metadata = nil
+	deployments := []cloudflare.PagesProjectDeployment{
+		testDeployment("main", "main", "production", 1, now),
+		testDeployment("develop", "develop", "preview", 5, now),
+		testDeployment("renovate", "renovate/major-react", "preview", 10, now),
+		testDeployment("feature", "feature/login", "preview", 20, now),
+		testDeployment("dependabot", "dependabot/npm_and_yarn/lodash-4.17.21", "preview", 45, now),
+		testDeployment("old-main", "main", "preview", 60, now),
+		unknown,
+	}
+	testCases := []struct {
+		name     string
+		flags    []string
+		stdin    string
+		expected []string
+	}{
+		{name: "Glob", flags: []string{"--branch", "dep*"}, expected: []string{"dependabot"}},
+		{name: "Multiple", flags: []string{"--branch", "main", "--branch", "renovate/*"}, expected: []string{"main", "renovate", "old-main"}},
+		{name: "Regex", flags: []string{"--branch-regex", "^(main|develop)$"}, expected: []string{"main", "develop", "old-main"}},
+		{name: "Exclude", flags: []string{"--branch", "*", "--exclude-branch", "main"}, expected: []string{"develop", "renovate", "feature", "dependabot"}},
+		{name: "Exclude included branch", flags: []string{"--branch", "main", "--branch", "develop", "--exclude-branch", "main"}, expected: []string{"develop"}},
+		{name: "Live branches", flags: []string{"--live-branches", "-"}, stdin: "origin/main\norigin/develop\n", expected: []string{"renovate", "feature", "dependabot"}},
+		{name: "Live branches without main", flags: []string{"--live-branches", "-", "--exclude-branch", "main"}, stdin: "origin/develop\n", expected: []string{"renovate", "feature", "dependabot"}},
+		{name: "Branch and age", flags: []string{"--branch", "dependabot/*", "--older-than", "30d"}, expected: []string{"dependabot"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			promptInput = strings.NewReader(tc.stdin)
+			selected, err := selectTestDeployments(now, deployments, tc.flags...)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, selected)
+			}
+		})
+	}
+
+	err := withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--exclude-branch", "main"})
+	assert.EqualError(t, err, "--exclude-branch needs --branch, --branch-regex or --live-branches")
+
+	err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--branch-regex", "feature-("})
+	assert.ErrorContains(t, err, "invalid branch-regex")
+
+	err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--keep-last", "5", "--branch-regex", "^renovate/"})
+	assert.EqualError(t, err, "cannot specify a retention policy with a branch or a time range")
+}
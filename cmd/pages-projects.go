@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v3"
+)
+
+const allProjectsFlag = "all-projects"
+
+// pagesProjectFlags are the flags of prune-deployments that select the projects to prune.
+func pagesProjectFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    projectNameFlag,
+			Aliases: []string{"p"},
+			Usage:   "Pages project to prune. Can be a glob such as preview-* to prune every matching project",
+			Sources: cli.EnvVars("CF_PAGES_PROJECT"),
+		},
+		&cli.BoolFlag{
+			Name:  allProjectsFlag,
+			Usage: "Prune every Pages project in the account",
+		},
+	}
+}
+
+// pruneProjectResult is the result of pruning a single project.
+type pruneProjectResult struct {
+	Project     string
+	Deployments int
+	Selected    int
+	Protected   int
+	Deleted     int
+	Err         error
+}
+
+// pagesProjectPattern returns the glob of the projects to prune, or an empty string if --project is a single project.
+func pagesProjectPattern(c *cli.Command) (string, error) {
+	project := c.String(projectNameFlag)
+	allProjects := c.Bool(allProjectsFlag)
+	switch {
+	case allProjects && project != "":
+		return "", fmt.Errorf("cannot specify both --%s and --%s", allProjectsFlag, projectNameFlag)
+	case allProjects:
+		return "*", nil
+	case project == "":
+		return "", fmt.Errorf("need to specify either --%s or --%s", projectNameFlag, allProjectsFlag)
+	case strings.ContainsAny(project, "*?["):
+		if _, err := path.Match(project, ""); err != nil {
+			return "", fmt.Errorf("invalid %s: %w", projectNameFlag, err)
+		}
+		return project, nil
+	}
+	return "", nil
+}
+
+// matchPagesProjects returns the names of the projects in the account that match the glob, sorted by name.
+func matchPagesProjects(ctx context.Context, pattern string) ([]string, error) {
+	projects, err := ListAllPagesProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, project := range projects {
+		// The pattern is checked by pagesProjectPattern, so Match cannot return an error.
+		if matched, _ := path.Match(pattern, project.Name); matched {
+			names = append(names, project.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no pages projects match %s", pattern)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// PruneProjectsDeployments prunes every project that matches the glob with the same selection.
+// A project that fails does not stop the other projects, and a table with the result of every project is printed at the end.
+func PruneProjectsDeployments(ctx context.Context, c *cli.Command, pattern string, selection deploymentSelection) error {
+	projects, err := matchPagesProjects(ctx, pattern)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Pruning %d pages projects", len(projects))
+
+	dryRun := c.Bool(dryRunFlag)
+	results := make([]pruneProjectResult, 0, len(projects))
+	failed := 0
+	for _, projectName := range projects {
+		if ctx.Err() != nil {
+			break
+		}
+		logger.Infof("Pruning project %s", projectName)
+		result, toDelete, err := selectProjectDeployments(ctx, c, projectName, selection)
+		if err == nil && len(toDelete) > 0 && !dryRun {
+			result.Deleted, err = deleteProjectDeployments(ctx, c, projectName, toDelete)
+		}
+		if err != nil {
+			logger.WithError(err).Errorf("Error pruning project %s", projectName)
+			result.Err = err
+			failed++
+		}
+		results = append(results, result)
+	}
+	printPruneProjectResults(os.Stdout, results, dryRun)
+
+	total := 0
+	for _, result := range results {
+		if dryRun {
+			total += result.Selected
+		} else {
+			total += result.Deleted
+		}
+	}
+	if dryRun {
+		fmt.Printf("Dry Run: would delete %d deployments across %d projects\n", total, len(results))
+	} else {
+		fmt.Printf("Deleted %d deployments across %d projects\n", total, len(results))
+	}
+
+	if len(results) < len(projects) {
+		return fmt.Errorf("canceled after pruning %d of %d projects: %w", len(results), len(projects), ctx.Err())
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to prune %d of %d projects", failed, len(results))
+	}
+	return nil
+}
+
+// printPruneProjectResults prints a table with the result of every project.
+func printPruneProjectResults(w io.Writer, results []pruneProjectResult, dryRun bool) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PROJECT\tDEPLOYMENTS\tSELECTED\tPROTECTED\tDELETED\tRESULT")
+	for _, result := range results {
+		var status string
+		switch {
+		case result.Err != nil:
+			status = "error: " + result.Err.Error()
+		case result.Selected == 0:
+			status = "nothing to delete"
+		case dryRun:
+			status = "dry run"
+		default:
+			status = "pruned"
+		}
+		fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%d\t%s\n", result.Project, result.Deployments, result.Selected, result.Protected, result.Deleted, status)
+	}
+	table.Flush()
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PruneDeployments_Projects(t *testing.T) {
+	testCases := []struct {
+		name     string
+		flags    []string
+		expected string
+		deleted  []string
+	}{
+		{
+			name:  "All projects",
+			flags: []string{"--all-projects", "--branch", "main"},
+			expected: "PROJECT                         DEPLOYMENTS  SELECTED  PROTECTED  DELETED  RESULT\n" +
+				"cloudflare-utils-pages-project  4            1         1          1        pruned\n" +
+				"docs-site                       0            0         0          0        nothing to delete\n" +
+				"Deleted 1 deployments across 2 projects\n",
+			deleted: []string{oldMainDeploymentID},
+		},
+		{
+			name:  "Glob",
+			flags: []string{"--project", "cloudflare-utils-*", "--older-than", "30d", "--dry-run"},
+			expected: "PROJECT                         DEPLOYMENTS  SELECTED  PROTECTED  DELETED  RESULT\n" +
+				"cloudflare-utils-pages-project  4            2         2          0        dry run\n" +
+				"Dry Run: would delete 2 deployments across 1 projects\n",
+		},
+		{
+			name:  "Retention",
+			flags: []string{"--all-projects", "--keep-last", "1", "--dry-run"},
+			expected: "PROJECT                         DEPLOYMENTS  SELECTED  PROTECTED  DELETED  RESULT\n" +
+				"cloudflare-utils-pages-project  4            1         0          0        dry run\n" +
+				"docs-site                       0            0         0          0        nothing to delete\n" +
+				"Dry Run: would delete 1 deployments across 2 projects\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var err error
+			output := captureStdout(t, func() {
+				err = withApp(t, append([]string{"cloudflare-utils", "prune-deployments"}, tc.flags...))
+			})
+			assert.NoError(t, err)
+			// Protected deployments are reported before the summary table.
+			_, summary, found := strings.Cut(output, "PROJECT ")
+			if assert.True(t, found, "Expected a summary table, got: %s", output) {
+				assert.Equal(t, tc.expected, "PROJECT "+summary)
+			}
+			assert.Equal(t, tc.deleted, testDeletedDeployments())
+		})
+	}
+
+	err := withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "blog-*", "--branch", "main"})
+	assert.EqualError(t, err, "no pages projects match blog-*")
+
+	err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--all-projects", "--branch", "main"})
+	assert.EqualError(t, err, "cannot specify both --all-projects and --project")
+
+	err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--branch", "main"})
+	assert.EqualError(t, err, "need to specify either --project or --all-projects")
+
+	err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "preview-[", "--branch", "main"})
+	assert.ErrorContains(t, err, "invalid project")
+}
+
+func Test_PrintPruneProjectResults(t *testing.T) {
+	results := []pruneProjectResult{
+		{Project: "blog", Deployments: 40, Selected: 12, Protected: 2},
+		{Project: "docs", Deployments: 3},
+		{Project: "shop", Err: errors.New("error listing deployments")},
+	}
+	var buf bytes.Buffer
+	printPruneProjectResults(&buf, results, true)
+	assert.Equal(t, "PROJECT  DEPLOYMENTS  SELECTED  PROTECTED  DELETED  RESULT\n"+
+		"blog     40           12        2          0        dry run\n"+
+		"docs     3            0         0          0        nothing to delete\n"+
+		"shop     0            0         0          0        error: error listing deployments\n", buf.String())
+
+	buf.Reset()
+	results[0].Deleted = 12
+	printPruneProjectResults(&buf, results[:1], false)
+	assert.Contains(t, buf.String(), "blog     40           12        2          12       pruned\n")
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/urfave/cli/v3"
+)
+
+const deleteProtectedFlag = "delete-protected"
+
+var deleteProtectedPagesFlag = &cli.BoolFlag{
+	Name:  deleteProtectedFlag,
+	Usage: "Also delete the live production deployment and deployments with aliases. Requires --force for the API to delete them",
+	Value: false,
+}
+
+// protectedDeploymentReasons returns why each deployment must not be deleted, by deployment ID.
+// The canonical deployment is what the production domain serves, and deployments with aliases back a branch or preview URL.
+func protectedDeploymentReasons(project cloudflare.PagesProject, deployments []cloudflare.PagesProjectDeployment) map[string]string {
+	reasons := make(map[string]string)
+	if project.CanonicalDeployment.ID != "" {
+		reasons[project.CanonicalDeployment.ID] = "live production deployment"
+	}
+	for _, deployment := range deployments {
+		if len(deployment.Aliases) == 0 {
+			continue
+		}
+		reason := "aliased as " + strings.Join(deployment.Aliases, ", ")
+		if existing, ok := reasons[deployment.ID]; ok {
+			reason = existing + ", " + reason
+		}
+		reasons[deployment.ID] = reason
+	}
+	return reasons
+}
+
+// excludeProtectedDeployments removes the live production deployment and deployments with aliases from the deployments to delete.
+// The project is fetched from the API so the production deployment is the one Cloudflare serves, not just the newest one.
+func excludeProtectedDeployments(ctx context.Context, projectName string, toDelete []cloudflare.PagesProjectDeployment) ([]cloudflare.PagesProjectDeployment, error) {
+	project, err := APIClient.GetPagesProject(ctx, accountRC, projectName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting project to find protected deployments: %w", err)
+	}
+	reasons := protectedDeploymentReasons(project, toDelete)
+	var skipped []string
+	remaining := slices.DeleteFunc(slices.Clone(toDelete), func(deployment cloudflare.PagesProjectDeployment) bool {
+		reason, protected := reasons[deployment.ID]
+		if protected {
+			skipped = append(skipped, fmt.Sprintf("  - %s (%s)", deployment.ID, reason))
+		}
+		return protected
+	})
+	if len(skipped) > 0 {
+		fmt.Printf("Skipping %d protected deployments. Use `--%s` to delete them:\n%s\n", len(skipped), deleteProtectedFlag, strings.Join(skipped, "\n"))
+	}
+	return remaining, nil
+}
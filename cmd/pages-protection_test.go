@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ProtectedDeploymentReasons(t *testing.T) {
+	now := time.Now()
+	production := testDeployment("production", "main", "production", 1, now)
+	production.Aliases = []string{"main.example.pages.dev"}
+	preview := testDeployment("preview", "feature", "preview", 2, now)
+	preview.Aliases = []string{"feature.example.pages.dev"}
+	deployments := []cloudflare.PagesProjectDeployment{
+		production,
+		preview,
+		testDeployment("old", "feature", "preview", 3, now),
+	}
+	project := cloudflare.PagesProject{CanonicalDeployment: cloudflare.PagesProjectDeployment{ID: "production"}}
+
+	assert.Equal(t, map[string]string{
+		"production": "live production deployment, aliased as main.example.pages.dev",
+		"preview":    "aliased as feature.example.pages.dev",
+	}, protectedDeploymentReasons(project, deployments))
+}
+
+func Test_PruneDeployments_Protected(t *testing.T) {
+	var err error
+	output := captureStdout(t, func() {
+		err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--branch", "*"})
+	})
+	assert.NoError(t, err, "Expected no error when the protected deployments are skipped")
+	assert.Equal(t, []string{dependabotDeploymentID, oldMainDeploymentID}, testDeletedDeployments(), "Expected the protected deployments not to be deleted")
+	assert.Contains(t, output, "Skipping 2 protected deployments. Use `--delete-protected` to delete them:\n"+
+		"  - "+aliasedDeploymentID+" (aliased as feature-login.test.pages.dev)\n"+
+		"  - "+productionDeploymentID+" (live production deployment)\n")
+
+	err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--branch", "main", "--delete-protected", "--force"})
+	assert.NoError(t, err, "Expected no error when deleting protected deployments")
+	assert.Equal(t, []string{productionDeploymentID, oldMainDeploymentID}, testDeletedDeployments(), "Expected the production deployment to be deleted")
+}
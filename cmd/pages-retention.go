@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	keepLastFlag       = "keep-last"
+	keepPerBranchFlag  = "keep-per-branch"
+	staleBranchAgeFlag = "stale-branch-age"
+
+	pagesRetentionCategory = "Retention"
+)
+
+// pagesRetentionFlags are the flags of the retention policies of prune-deployments.
+func pagesRetentionFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:     keepLastFlag,
+			Usage:    "Keep the newest N deployments of the project and delete the rest",
+			Category: pagesRetentionCategory,
+		},
+		&cli.IntFlag{
+			Name:     keepPerBranchFlag,
+			Usage:    "Keep the newest N deployments of every branch and delete the rest",
+			Category: pagesRetentionCategory,
+		},
+		&cli.StringFlag{
+			Name:     staleBranchAgeFlag,
+			Usage:    "Delete every deployment of branches whose latest deployment is older than this age, such as 30d",
+			Category: pagesRetentionCategory,
+		},
+	}
+}
+
+// deploymentRetention is a retention policy for the deployments of a Pages project.
+// The current production deployment and the latest deployment of every branch that is not stale are always kept.
+type deploymentRetention struct {
+	keepLast      int
+	keepPerBranch int
+	staleBefore   time.Time
+}
+
+// newDeploymentRetention builds the retention policy from the CLI flags. It returns nil if no policy is set.
+func newDeploymentRetention(c *cli.Command, now time.Time) (*deploymentRetention, error) {
+	retention := &deploymentRetention{
+		keepLast:      c.Int(keepLastFlag),
+		keepPerBranch: c.Int(keepPerBranchFlag),
+	}
+	if retention.keepLast < 0 {
+		return nil, fmt.Errorf("%s cannot be negative", keepLastFlag)
+	}
+	if retention.keepPerBranch < 0 {
+		return nil, fmt.Errorf("%s cannot be negative", keepPerBranchFlag)
+	}
+	if value := c.String(staleBranchAgeFlag); value != "" {
+		age, err := ParseRelativeDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", staleBranchAgeFlag, err)
+		}
+		retention.staleBefore = now.Add(-age)
+	}
+	if retention.keepLast == 0 && retention.keepPerBranch == 0 && retention.staleBefore.IsZero() {
+		return nil, nil
+	}
+	return retention, nil
+}
+
+// deploymentBranch returns the branch of a deployment, or an empty string if the deployment has no metadata.
+func deploymentBranch(deployment cloudflare.PagesProjectDeployment) string {
+	if deployment.DeploymentTrigger.Metadata == nil {
+		return ""
+	}
+	return deployment.DeploymentTrigger.Metadata.Branch
+}
+
+// Select returns the deployments the retention policy deletes.
+// With --keep-last or --keep-per-branch, every deployment that is not kept by one of them is deleted.
+// Deployments of stale branches are deleted even if another policy would keep them, except for the current production deployment.
+func (r *deploymentRetention) Select(deployments []cloudflare.PagesProjectDeployment) []cloudflare.PagesProjectDeployment {
+	newest := slices.Clone(deployments)
+	slices.SortStableFunc(newest, func(a, b cloudflare.PagesProjectDeployment) int {
+		return b.CreatedOn.Compare(*a.CreatedOn)
+	})
+
+	keep := make(map[string]bool, len(newest))
+	latestByBranch := make(map[string]cloudflare.PagesProjectDeployment)
+	perBranch := make(map[string]int)
+	productionID := ""
+	for i, deployment := range newest {
+		branch := deploymentBranch(deployment)
+		if productionID == "" && deployment.Environment == "production" {
+			logger.Debugf("Keeping current production deployment %s", deployment.ID)
+			keep[deployment.ID] = true
+			productionID = deployment.ID
+		}
+		if _, ok := latestByBranch[branch]; !ok {
+			latestByBranch[branch] = deployment
+		}
+		if i < r.keepLast || perBranch[branch] < r.keepPerBranch {
+			keep[deployment.ID] = true
+		}
+		perBranch[branch]++
+	}
+
+	stale := make(map[string]bool)
+	for branch, latest := range latestByBranch {
+		if !r.staleBefore.IsZero() && latest.CreatedOn.Before(r.staleBefore) {
+			logger.Debugf("Branch %s is stale. Latest deployment was on %s", branch, latest.CreatedOn.Format(time.DateOnly))
+			stale[branch] = true
+			continue
+		}
+		keep[latest.ID] = true
+	}
+	if !r.staleBefore.IsZero() {
+		logger.Infof("Found %d stale branches of %d", len(stale), len(latestByBranch))
+	}
+
+	retainAll := r.keepLast == 0 && r.keepPerBranch == 0
+	var toDelete []cloudflare.PagesProjectDeployment
+	for _, deployment := range newest {
+		switch {
+		case deployment.ID == productionID:
+			continue
+		case stale[deploymentBranch(deployment)]:
+			toDelete = append(toDelete, deployment)
+		case !retainAll && !keep[deployment.ID]:
+			toDelete = append(toDelete, deployment)
+		}
+	}
+	logger.Debugf("Retention policy keeps %d of %d deployments", len(newest)-len(toDelete), len(newest))
+	return toDelete
+}
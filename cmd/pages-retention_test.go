@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDeployment returns a deployment of the branch created the number of days before now.
+func testDeployment(id, branch, environment string, daysAgo int, now time.Time) cloudflare.PagesProjectDeployment {
+	created := now.AddDate(0, 0, -daysAgo)
+	deployment := cloudflare.PagesProjectDeployment{ID: id, Environment: environment, CreatedOn: &created}
+	deployment.DeploymentTrigger.Metadata = &cloudflare.PagesProjectDeploymentTriggerMetadata{Branch: branch}
+	return deployment
+}
+
+func deploymentIDs(deployments []cloudflare.PagesProjectDeployment) []string {
+	ids := make([]string, 0, len(deployments))
+	for _, deployment := range deployments {
+		ids = append(ids, deployment.ID)
+	}
+	return ids
+}
+
+func Test_DeploymentRetention(t *testing.T) {
+	logger = logrus.New()
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	deployments := []cloudflare.PagesProjectDeployment{
+		testDeployment("main-old", "main", "production", 40, now),
+		testDeployment("main-new", "main", "production", 50, now),
+		testDeployment("main-current", "main", "production", 1, now),
+		testDeployment("feature-1", "feature", "preview", 10, now),
+		testDeployment("feature-2", "feature", "preview", 5, now),
+		testDeployment("feature-3", "feature", "preview", 3, now),
+		testDeployment("stale-1", "stale", "preview", 90, now),
+		testDeployment("stale-2", "stale", "preview", 60, now),
+	}
+
+	testCases := []struct {
+		name      string
+		retention deploymentRetention
+		expected  []string
+	}{
+		{
+			name:      "Keep last",
+			retention: deploymentRetention{keepLast: 2},
+			// The latest deployment of every branch is always kept.
+			expected: []string{"feature-2", "feature-1", "main-old", "main-new", "stale-1"},
+		},
+		{
+			name:      "Keep per branch",
+			retention: deploymentRetention{keepPerBranch: 2},
+			expected:  []string{"feature-1", "main-new"},
+		},
+		{
+			name:      "Stale branches",
+			retention: deploymentRetention{staleBefore: now.AddDate(0, 0, -30)},
+			expected:  []string{"stale-2", "stale-1"},
+		},
+		{
+			name:      "Keep per branch and stale branches",
+			retention: deploymentRetention{keepPerBranch: 1, staleBefore: now.AddDate(0, 0, -30)},
+			expected:  []string{"feature-2", "feature-1", "main-old", "main-new", "stale-2", "stale-1"},
+		},
+		{
+			name:      "Production is kept in a stale branch",
+			retention: deploymentRetention{staleBefore: now},
+			expected:  []string{"feature-3", "feature-2", "feature-1", "main-old", "main-new", "stale-2", "stale-1"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, deploymentIDs(tc.retention.Select(deployments)))
+		})
+	}
+}
+
+func Test_PruneDeployments_Retention(t *testing.T) {
+	err := withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--keep-last", "5", "--dry-run"})
+	assert.NoError(t, err, "Expected no error when pruning with a retention policy")
+
+	err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--stale-branch-age", "30d"})
+	assert.NoError(t, err, "Expected the production deployment to be kept")
+
+	err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--keep-last", "5", "--branch", "main"})
+	assert.EqualError(t, err, "cannot specify a retention policy with a branch or a time range")
+
+	err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--stale-branch-age", "soon"})
+	assert.ErrorContains(t, err, "invalid stale-branch-age")
+
+	err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project"})
+	assert.EqualError(t, err, "need to specify either a branch, a time or a retention policy")
+}
@@ -4,20 +4,32 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/cloudflare/cloudflare-go"
 	"github.com/urfave/cli/v3"
 )
 
 const (
-	branchNameFlag = "branch"
-	beforeFlag     = "before"
-	afterFlag      = "after"
-	//timeShortcutFlag = "time" Not implemented yet.
+	branchNameFlag     = "branch"
+	beforeFlag         = "before"
+	afterFlag          = "after"
+	olderThanFlag      = "older-than"
+	newerThanFlag      = "newer-than"
 	persistRetry       = "persist-retry"
 	persistRetryAmount = "persist-retry-amount"
 )
 
+// pagesProjectFlag is the project flag of purge-deployments. prune-deployments can select more than one project, see pagesProjectFlags.
+var pagesProjectFlag = &cli.StringFlag{
+	Name:     projectNameFlag,
+	Aliases:  []string{"p"},
+	Usage:    "Pages project to delete the alias from",
+	Required: true,
+	Sources:  cli.EnvVars("CF_PAGES_PROJECT"),
+}
+
 var sharedPagesFlags = []cli.Flag{
 	&cli.BoolFlag{
 		Name:  dryRunFlag,
@@ -34,13 +46,6 @@ var sharedPagesFlags = []cli.Flag{
 		Usage: "Number of times to retry the delete if it fails",
 		Value: 10,
 	},
-	&cli.StringFlag{
-		Name:     projectNameFlag,
-		Aliases:  []string{"p"},
-		Usage:    "Pages project to delete the alias from",
-		Required: true,
-		Sources:  cli.EnvVars("CF_PAGES_PROJECT"),
-	},
 	&cli.BoolFlag{
 		Name:  lotsOfDeploymentsFlag,
 		Usage: "If you are getting errors getting all of the deployments, you may need to use this flag.",
@@ -53,10 +58,70 @@ var sharedPagesFlags = []cli.Flag{
 	},
 }
 
+// deploymentTimeLayouts are the layouts accepted by --before and --after.
+var deploymentTimeLayouts = []string{"2006-01-02T15:04:05", time.RFC3339, time.DateOnly}
+
 type pruneDeploymentOptions struct {
 	c                   *cli.Command
 	ProjectName         string
 	SelectedDeployments []cloudflare.PagesProjectDeployment
+	TimeWindow          deploymentTimeWindow
+}
+
+// deploymentSelection is how prune-deployments selects the deployments to delete.
+// It is built once by PruneDeploymentsScreen, because --live-branches can read stdin. A zero selection deletes every deployment.
+type deploymentSelection struct {
+	Branches   *deploymentBranchFilter
+	TimeWindow deploymentTimeWindow
+	Retention  *deploymentRetention
+}
+
+// deploymentTimeWindow selects deployments created between After and Before. A zero bound is not checked.
+type deploymentTimeWindow struct {
+	Before time.Time
+	After  time.Time
+}
+
+// newDeploymentTimeWindow builds the time window from --before, --after, --older-than and --newer-than.
+// The relative flags are durations before now, so --older-than 30d is the same as --before set to 30 days ago.
+func newDeploymentTimeWindow(c *cli.Command, now time.Time) (deploymentTimeWindow, error) {
+	window := deploymentTimeWindow{Before: c.Timestamp(beforeFlag), After: c.Timestamp(afterFlag)}
+	relativeBounds := []struct {
+		flag, absoluteFlag string
+		bound              *time.Time
+	}{
+		{olderThanFlag, beforeFlag, &window.Before},
+		{newerThanFlag, afterFlag, &window.After},
+	}
+	for _, relative := range relativeBounds {
+		value := c.String(relative.flag)
+		if value == "" {
+			continue
+		}
+		if !relative.bound.IsZero() {
+			return deploymentTimeWindow{}, fmt.Errorf("cannot specify both --%s and --%s", relative.flag, relative.absoluteFlag)
+		}
+		age, err := ParseRelativeDuration(value)
+		if err != nil {
+			return deploymentTimeWindow{}, fmt.Errorf("invalid %s: %w", relative.flag, err)
+		}
+		*relative.bound = now.Add(-age)
+	}
+	if !window.Before.IsZero() && !window.After.IsZero() && !window.After.Before(window.Before) {
+		return deploymentTimeWindow{}, fmt.Errorf("time window is empty: deployments cannot be created after %s and before %s",
+			window.After.Format(time.RFC3339), window.Before.Format(time.RFC3339))
+	}
+	return window, nil
+}
+
+// IsZero checks if neither bound of the window is set.
+func (w deploymentTimeWindow) IsZero() bool {
+	return w.Before.IsZero() && w.After.IsZero()
+}
+
+// Contains checks if a time is inside the window.
+func (w deploymentTimeWindow) Contains(t time.Time) bool {
+	return (w.Before.IsZero() || t.Before(w.Before)) && (w.After.IsZero() || t.After(w.After))
 }
 
 func buildPruneDeploymentsCommand() *cli.Command {
@@ -65,39 +130,83 @@ func buildPruneDeploymentsCommand() *cli.Command {
 		Usage:  "Prune deployments by either branch of time\nAPI Token Requirements: Pages:Edit",
 		Action: PruneDeploymentsScreen,
 		Flags: append([]cli.Flag{
-			&cli.StringFlag{
-				Name:    branchNameFlag,
-				Aliases: []string{"b"},
-				Usage:   "Branch to delete",
-				Sources: cli.EnvVars("CF_PAGES_BRANCH"),
-			},
 			&cli.TimestampFlag{
 				Name:  beforeFlag,
-				Usage: "Time to delete before",
+				Usage: "Time to delete before. Either 2006-01-02T15:04:05, an RFC 3339 timestamp or a date (2006-01-02)",
 				Config: cli.TimestampConfig{
-					Layouts: []string{"2006-01-02T15:04:05"},
+					Layouts: deploymentTimeLayouts,
 				},
 			},
 			&cli.TimestampFlag{
 				Name:  afterFlag,
-				Usage: "Time to delete after",
+				Usage: "Time to delete after. Can be used with --before to delete deployments in a window",
 				Config: cli.TimestampConfig{
-					Layouts: []string{"2006-01-02T15:04:05"},
+					Layouts: deploymentTimeLayouts,
 				},
 			},
-			//&cli.DurationFlag{
-			//	Name: timeShortcutFlag,
-			//	Usage: "Shortcut for before and after. "+
-			//		"Use the format of 1<unit> where the unit is one of "+
-			//		"y (year), M (month), w (week), d (day), h (hour), m (minute), s (second)" +
-			//		"use a negative number to go back in time. Read the docs for more info",
-			//},
-		}, sharedPagesFlags...),
+			&cli.StringFlag{
+				Name: olderThanFlag,
+				Usage: "Delete deployments older than this age. Shortcut for --before. " +
+					"Use the format of 1<unit> where the unit is one of y (year), M (month), w (week), d (day), h (hour), m (minute), s (second)",
+			},
+			&cli.StringFlag{
+				Name:  newerThanFlag,
+				Usage: "Delete deployments newer than this age. Shortcut for --after",
+			},
+			deleteProtectedPagesFlag,
+		}, slices.Concat(pagesProjectFlags(), pagesBranchFlags(), pagesRetentionFlags(), sharedPagesFlags)...),
 	}
 }
 
+// newDeploymentSelection builds the selection from the time, retention and branch flags.
+func newDeploymentSelection(ctx context.Context, c *cli.Command, now time.Time) (deploymentSelection, error) {
+	var selection deploymentSelection
+	var err error
+	if selection.TimeWindow, err = newDeploymentTimeWindow(c, now); err != nil {
+		return deploymentSelection{}, err
+	}
+	if selection.Retention, err = newDeploymentRetention(c, now); err != nil {
+		return deploymentSelection{}, err
+	}
+	if selection.Branches, err = newDeploymentBranchFilter(ctx, c); err != nil {
+		return deploymentSelection{}, err
+	}
+	byBranch := !selection.Branches.IsZero()
+
+	if selection.Retention != nil && (byBranch || !selection.TimeWindow.IsZero()) {
+		return deploymentSelection{}, errors.New("cannot specify a retention policy with a branch or a time range")
+	}
+	if selection.IsZero() {
+		return deploymentSelection{}, errors.New("need to specify either a branch, a time or a retention policy")
+	}
+	return selection, nil
+}
+
+// IsZero checks if the selection has no branch, time window or retention policy.
+func (s deploymentSelection) IsZero() bool {
+	return s.Retention == nil && (s.Branches == nil || s.Branches.IsZero()) && s.TimeWindow.IsZero()
+}
+
+// Select returns the deployments the selection deletes. A zero selection selects every deployment.
+func (s deploymentSelection) Select(deployments []cloudflare.PagesProjectDeployment) []cloudflare.PagesProjectDeployment {
+	if s.Retention != nil {
+		logger.Infoln("Pruning by retention policy")
+		return s.Retention.Select(deployments)
+	}
+	toDelete := deployments
+	if s.Branches != nil && !s.Branches.IsZero() {
+		logger.Infoln("Pruning by branch")
+		toDelete = PruneBranchDeployments(s.Branches, pruneDeploymentOptions{SelectedDeployments: toDelete})
+	}
+	if !s.TimeWindow.IsZero() {
+		logger.Infoln("Pruning by time")
+		toDelete = PruneTimeDeployments(pruneDeploymentOptions{SelectedDeployments: toDelete, TimeWindow: s.TimeWindow})
+	}
+	return toDelete
+}
+
 // PruneDeploymentsScreen is the entry point for the prune-deployments command.
-// It handles parsing the CLI arguments and then calls PruneDeploymentsRoot.
+// It handles parsing the CLI arguments and then calls PruneDeploymentsRoot, or PruneProjectsDeployments to prune more than one project.
 func PruneDeploymentsScreen(ctx context.Context, c *cli.Command) error {
 	logger.Info("Staring prune deployments")
 	if err := CheckAPITokenPermission(ctx, PagesWrite); err != nil {
@@ -107,59 +216,28 @@ func PruneDeploymentsScreen(ctx context.Context, c *cli.Command) error {
 	if accountID == "" {
 		return errors.New("`account-id` is required for pages commands")
 	}
-
-	if c.String(branchNameFlag) != "" && (!c.Timestamp(beforeFlag).IsZero() || !c.Timestamp(afterFlag).IsZero()) {
-		return errors.New("cannot specify both a branch and a time range")
+	projectPattern, err := pagesProjectPattern(c)
+	if err != nil {
+		return err
 	}
 
-	beforeTime := c.Timestamp(beforeFlag)
-	afterTime := c.Timestamp(afterFlag)
-
-	if c.String(branchNameFlag) == "" && beforeTime.IsZero() && afterTime.IsZero() {
-		return errors.New("need to specify either a branch or a time")
+	selection, err := newDeploymentSelection(ctx, c, time.Now())
+	if err != nil {
+		return err
+	}
+	if projectPattern != "" {
+		return PruneProjectsDeployments(ctx, c, projectPattern, selection)
 	}
-	return PruneDeploymentsRoot(ctx, c)
+	return PruneDeploymentsRoot(ctx, c, selection)
 }
 
 // PruneDeploymentsRoot is the main function for pruning and purging deployments.
-func PruneDeploymentsRoot(ctx context.Context, c *cli.Command) error {
+func PruneDeploymentsRoot(ctx context.Context, c *cli.Command, selection deploymentSelection) error {
 	projectName := c.String(projectNameFlag)
 
-	allDeployments, err := DeploymentsPaginate(
-		PagesDeploymentPaginationOptions{
-			CLIContext:  c,
-			ctx:         ctx,
-			ProjectName: projectName,
-		})
+	_, toDelete, err := selectProjectDeployments(ctx, c, projectName, selection)
 	if err != nil {
-		return fmt.Errorf("error listing deployments: %w", err)
-	}
-
-	options := pruneDeploymentOptions{
-		c:                   c,
-		ProjectName:         projectName,
-		SelectedDeployments: allDeployments,
-	}
-
-	var toDelete []cloudflare.PagesProjectDeployment
-	branch := c.String(branchNameFlag)
-	before := c.Timestamp(beforeFlag)
-	after := c.Timestamp(afterFlag)
-
-	preventPurgeAll := c.Name == "prune-deployments"
-
-	if branch != "" {
-		logger.Infof("Pruning by branch: %s", branch)
-		toDelete = PruneBranchDeployments(branch, options)
-	} else if !before.IsZero() || !after.IsZero() {
-		logger.Infoln("Pruning by time")
-		toDelete = PruneTimeDeployments(options)
-	} else {
-		if preventPurgeAll {
-			return errors.New("refusing to delete all deployments when a branch or time was specified. This is a safety feature to prevent accidental deletion of all deployments")
-		}
-		logger.Infoln("Purging all deployments")
-		toDelete = options.SelectedDeployments
+		return err
 	}
 
 	if len(toDelete) == 0 {
@@ -172,11 +250,10 @@ func PruneDeploymentsRoot(ctx context.Context, c *cli.Command) error {
 		return nil
 	}
 
-	options.SelectedDeployments = toDelete
-	failedDeletes := RapidPagesDeploymentDelete(options)
-	fmt.Printf("Deleted %d deployments\n", len(toDelete)-len(failedDeletes))
-	if len(failedDeletes) > 0 {
-		return fmt.Errorf("failed to delete %d deployments", len(failedDeletes))
+	deleted, err := deleteProjectDeployments(ctx, c, projectName, toDelete)
+	fmt.Printf("Deleted %d deployments\n", deleted)
+	if err != nil {
+		return err
 	}
 	if c.Bool(deleteProjectFlag) {
 		fmt.Printf("Deleting project: %s\n", projectName)
@@ -187,42 +264,88 @@ func PruneDeploymentsRoot(ctx context.Context, c *cli.Command) error {
 	return nil
 }
 
-// PruneBranchDeployments will return a list of deployments to delete based on the branch name.
-func PruneBranchDeployments(branch string, options pruneDeploymentOptions) []cloudflare.PagesProjectDeployment {
+// selectProjectDeployments lists the deployments of a project and returns the ones the selection deletes.
+func selectProjectDeployments(ctx context.Context, c *cli.Command, projectName string, selection deploymentSelection) (pruneProjectResult, []cloudflare.PagesProjectDeployment, error) {
+	result := pruneProjectResult{Project: projectName}
+	allDeployments, err := DeploymentsPaginate(
+		PagesDeploymentPaginationOptions{
+			CLIContext:  c,
+			ctx:         ctx,
+			ProjectName: projectName,
+		})
+	if err != nil {
+		return result, nil, fmt.Errorf("error listing deployments: %w", err)
+	}
+	result.Deployments = len(allDeployments)
+
+	preventPurgeAll := c.Name == "prune-deployments"
+	if selection.IsZero() {
+		if preventPurgeAll {
+			return result, nil, errors.New("refusing to delete all deployments when a branch or time was specified. This is a safety feature to prevent accidental deletion of all deployments")
+		}
+		logger.Infoln("Purging all deployments")
+	}
+	toDelete := selection.Select(allDeployments)
+
+	// purge-deployments deletes every deployment, so only prune-deployments protects deployments.
+	if preventPurgeAll && !c.Bool(deleteProtectedFlag) && len(toDelete) > 0 {
+		selected := len(toDelete)
+		if toDelete, err = excludeProtectedDeployments(ctx, projectName, toDelete); err != nil {
+			return result, nil, err
+		}
+		result.Protected = selected - len(toDelete)
+	}
+	result.Selected = len(toDelete)
+	return result, toDelete, nil
+}
+
+// deleteProjectDeployments deletes the deployments of a project and returns how many were deleted.
+func deleteProjectDeployments(ctx context.Context, c *cli.Command, projectName string, toDelete []cloudflare.PagesProjectDeployment) (int, error) {
+	report := RapidPagesDeploymentDelete(ctx, pruneDeploymentOptions{
+		c:                   c,
+		ProjectName:         projectName,
+		SelectedDeployments: toDelete,
+	})
+	if canceled := report.Canceled(); canceled > 0 {
+		return report.Succeeded(), fmt.Errorf("canceled before deleting %d deployments: %w", canceled, ctx.Err())
+	}
+	if failed := report.Failed(); len(failed) > 0 {
+		return report.Succeeded(), fmt.Errorf("failed to delete %d deployments", len(failed))
+	}
+	return report.Succeeded(), nil
+}
+
+// PruneBranchDeployments will return a list of deployments to delete based on the branch filter.
+func PruneBranchDeployments(filter *deploymentBranchFilter, options pruneDeploymentOptions) []cloudflare.PagesProjectDeployment {
 	var toDelete []cloudflare.PagesProjectDeployment
-	logger.Debugf("Got %d deployments to check for branch: %s", len(options.SelectedDeployments), branch)
+	logger.Debugf("Got %d deployments to check by branch", len(options.SelectedDeployments))
 	for _, deployment := range options.SelectedDeployments {
 		if deployment.DeploymentTrigger.Metadata == nil {
 			logger.Debugln("No metadata for deployment, skipping")
 			continue
 		}
-		logger.Tracef("Got deployment branch: %s", deployment.DeploymentTrigger.Metadata.Branch)
-		if deployment.DeploymentTrigger.Metadata.Branch == branch {
+		branch := deploymentBranch(deployment)
+		logger.Tracef("Got deployment branch: %s", branch)
+		if filter.Match(branch) {
 			toDelete = append(toDelete, deployment)
 		}
 	}
-	logger.Debugf("Found %d deployments to delete by branch: %s", len(toDelete), branch)
+	logger.Debugf("Found %d deployments to delete by branch", len(toDelete))
 	return toDelete
 }
 
-// PruneTimeDeployments will return a list of deployments to delete based on the time range.
+// PruneTimeDeployments will return a list of deployments to delete based on the time window.
 func PruneTimeDeployments(options pruneDeploymentOptions) (toDelete []cloudflare.PagesProjectDeployment) {
-	beforeTimestamp := options.c.Timestamp(beforeFlag)
-	afterTimestamp := options.c.Timestamp(afterFlag)
-	if !beforeTimestamp.IsZero() {
-		logger.Debugln("Pruning with before time")
-	} else {
-		logger.Debugln("Pruning with after time")
+	window := options.TimeWindow
+	if !window.Before.IsZero() {
+		logger.Debugf("Pruning deployments created before %s", window.Before.Format(time.RFC3339))
+	}
+	if !window.After.IsZero() {
+		logger.Debugf("Pruning deployments created after %s", window.After.Format(time.RFC3339))
 	}
 	for _, deployment := range options.SelectedDeployments {
-		if !beforeTimestamp.IsZero() {
-			if deployment.CreatedOn.Before(beforeTimestamp) {
-				toDelete = append(toDelete, deployment)
-			}
-		} else {
-			if deployment.CreatedOn.After(afterTimestamp) {
-				toDelete = append(toDelete, deployment)
-			}
+		if window.Contains(*deployment.CreatedOn) {
+			toDelete = append(toDelete, deployment)
 		}
 	}
 	return toDelete
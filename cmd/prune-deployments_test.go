@@ -1,22 +1,127 @@
 package cmd
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	// productionDeploymentID is the canonical deployment of the test pages project.
+	productionDeploymentID = "0012e50b-fa5d-44db-8cb5-1f372785dcbe"
+	// aliasedDeploymentID is the feature/login deployment of the test pages project, which has an alias.
+	aliasedDeploymentID = "4f2d1c3b-8a9e-4b7c-9d6e-5f4a3b2c1d0e"
+	// dependabotDeploymentID is a preview deployment of the test pages project without an alias.
+	dependabotDeploymentID = "7b1e9a2c-3d4f-4e5a-8b6c-9d0e1f2a3b4c"
+	// oldMainDeploymentID is a preview deployment of the main branch created before 2006 without an alias.
+	oldMainDeploymentID = "9c8d7e6f-5a4b-4c3d-8e1f-0a9b8c7d6e5f"
 )
 
 func Test_PruneDeployments_Branch(t *testing.T) {
 	err := withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--branch", "main"})
 	assert.NoError(t, err, "Expected no error when running the app with dry-run flag")
+	assert.Equal(t, []string{oldMainDeploymentID}, testDeletedDeployments(), "Expected only the unprotected main deployment to be deleted")
 }
 
 func Test_PruneDeployments_TimeBefore(t *testing.T) {
 	err := withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--before", "2006-01-02T15:04:05"})
 	assert.NoError(t, err, "Expected no error when running the app with dry-run flag")
+	assert.Equal(t, []string{oldMainDeploymentID}, testDeletedDeployments(), "Expected only the deployment created before 2006 to be deleted")
 }
 
 func Test_PruneDeployments_TimeAfter(t *testing.T) {
 	err := withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--after", "2006-01-02T15:04:05"})
 	assert.NoError(t, err, "Expected no error when running the app with dry-run flag")
+	assert.Equal(t, []string{dependabotDeploymentID}, testDeletedDeployments(), "Expected the production and aliased deployments to be kept")
+}
+
+func Test_PruneTimeDeployments(t *testing.T) {
+	logger = logrus.New()
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	deployments := []cloudflare.PagesProjectDeployment{
+		testDeployment("old", "main", "production", 90, now),
+		testDeployment("middle", "main", "preview", 20, now),
+		testDeployment("new", "main", "preview", 1, now),
+	}
+	testCases := []struct {
+		name     string
+		window   deploymentTimeWindow
+		expected []string
+	}{
+		{name: "Before", window: deploymentTimeWindow{Before: now.AddDate(0, 0, -10)}, expected: []string{"old", "middle"}},
+		{name: "After", window: deploymentTimeWindow{After: now.AddDate(0, 0, -10)}, expected: []string{"new"}},
+		{name: "Window", window: deploymentTimeWindow{Before: now.AddDate(0, 0, -10), After: now.AddDate(0, 0, -30)}, expected: []string{"middle"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			toDelete := PruneTimeDeployments(pruneDeploymentOptions{SelectedDeployments: deployments, TimeWindow: tc.window})
+			assert.Equal(t, tc.expected, deploymentIDs(toDelete))
+		})
+	}
+}
+
+// selectTestDeployments parses the prune-deployments flags and returns the IDs of the deployments they select.
+func selectTestDeployments(now time.Time, deployments []cloudflare.PagesProjectDeployment, flags ...string) ([]string, error) {
+	var selected []string
+	command := buildPruneDeploymentsCommand()
+	command.Action = func(ctx context.Context, c *cli.Command) error {
+		selection, err := newDeploymentSelection(ctx, c, now)
+		if err != nil {
+			return err
+		}
+		selected = deploymentIDs(selection.Select(deployments))
+		return nil
+	}
+	err := command.Run(context.Background(), append([]string{"prune-deployments"}, flags...))
+	return selected, err
+}
+
+func Test_PruneDeployments_TimeWindow(t *testing.T) {
+	logger = logrus.New()
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	deployments := []cloudflare.PagesProjectDeployment{
+		testDeployment("production", "main", "production", 400, now),
+		testDeployment("feature", "feature/login", "preview", 45, now),
+		testDeployment("dependabot", "dependabot/npm_and_yarn/lodash-4.17.21", "preview", 10, now),
+		testDeployment("today", "main", "preview", 0, now),
+	}
+	testCases := []struct {
+		name     string
+		flags    []string
+		expected []string
+	}{
+		{name: "Before", flags: []string{"--before", "2026-01-01T00:00:00"}, expected: []string{"production"}},
+		{name: "Before RFC 3339", flags: []string{"--before", "2026-05-01T00:00:00Z"}, expected: []string{"production", "feature"}},
+		{name: "Before date", flags: []string{"--before", "2026-05-25"}, expected: []string{"production", "feature", "dependabot"}},
+		{name: "After date", flags: []string{"--after", "2026-05-01"}, expected: []string{"dependabot", "today"}},
+		{name: "Window", flags: []string{"--after", "2026-01-01", "--before", "2026-05-20T00:00:00Z"}, expected: []string{"feature"}},
+		{name: "Older than", flags: []string{"--older-than", "30d"}, expected: []string{"production", "feature"}},
+		{name: "Newer than", flags: []string{"--newer-than", "2w"}, expected: []string{"dependabot", "today"}},
+		{name: "Relative window", flags: []string{"--newer-than", "1y", "--older-than", "1w"}, expected: []string{"feature", "dependabot"}},
+		{name: "Branch and window", flags: []string{"--branch", "main", "--older-than", "1y"}, expected: []string{"production"}},
+		{name: "Branch and newer than", flags: []string{"--branch", "main", "--newer-than", "1d"}, expected: []string{"today"}},
+		{name: "Branch and no match", flags: []string{"--branch", "feature/*", "--newer-than", "1w"}, expected: []string{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			selected, err := selectTestDeployments(now, deployments, tc.flags...)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, selected)
+			}
+		})
+	}
+
+	err := withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--before", "2022-01-01", "--older-than", "30d"})
+	assert.EqualError(t, err, "cannot specify both --older-than and --before")
+
+	err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--before", "2020-01-01", "--after", "2022-01-01"})
+	assert.EqualError(t, err, "time window is empty: deployments cannot be created after 2022-01-01T00:00:00Z and before 2020-01-01T00:00:00Z")
+
+	err = withApp(t, []string{"cloudflare-utils", "prune-deployments", "--project", "cloudflare-utils-pages-project", "--newer-than", "yesterday"})
+	assert.ErrorContains(t, err, "invalid newer-than")
 }
@@ -22,6 +22,7 @@ func buildPurgeDeploymentsCommand() *cli.Command {
 				Usage: "Delete the project as well. Will attempt to delete the project even if there are errors deleting deployments.",
 				Value: false,
 			},
+			pagesProjectFlag,
 		}, sharedPagesFlags...),
 	}
 }
@@ -33,5 +34,5 @@ func PurgeDeploymentsScreen(ctx context.Context, c *cli.Command) error {
 	if err := CheckAPITokenPermission(ctx, PagesWrite); err != nil {
 		return err
 	}
-	return PruneDeploymentsRoot(ctx, c)
+	return PruneDeploymentsRoot(ctx, c, deploymentSelection{})
 }
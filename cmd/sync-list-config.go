@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const syncListConfigFlag = "config"
+
+// SyncListConfigFile is the struct of the YAML file used to sync multiple lists in one run.
+type SyncListConfigFile struct {
+	Lists []*SyncListConfig `yaml:"lists"`
+}
+
+// SyncListConfig is a single list to sync. Settings that are not set use the value of the matching flag.
+type SyncListConfig struct {
+	Name        string   `yaml:"name"`
+	ID          string   `yaml:"id,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+	Kind        string   `yaml:"kind,omitempty"`
+	Sources     []string `yaml:"sources"`
+	IPVersion   string   `yaml:"ip_version,omitempty"`
+	Comment     string   `yaml:"comment,omitempty"`
+	NoComment   *bool    `yaml:"no_comment,omitempty"`
+	Mode        string   `yaml:"mode,omitempty"`
+	Aggregate   *bool    `yaml:"aggregate,omitempty"`
+	SkipInvalid *bool    `yaml:"skip_invalid,omitempty"`
+	Format      string   `yaml:"format,omitempty"`
+	JSONPaths   []string `yaml:"json_path,omitempty"`
+	CSVColumn   string   `yaml:"csv_column,omitempty"`
+	MaxRemovals string   `yaml:"max_removals,omitempty"`
+	MinItems    *int     `yaml:"min_items,omitempty"`
+}
+
+// syncListResult is the outcome of syncing one list from a config file.
+type syncListResult struct {
+	List  string
+	Kind  string
+	Items int
+	Err   error
+}
+
+// loadSyncListConfig reads a config file and returns the options of every list, using defaults for anything not set.
+func loadSyncListConfig(filePath string, defaults syncListOptions) ([]syncListOptions, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+	configFile := &SyncListConfigFile{}
+	if err := yaml.Unmarshal(data, configFile); err != nil {
+		return nil, fmt.Errorf("error parsing config file: %w", err)
+	}
+	if len(configFile.Lists) == 0 {
+		return nil, errors.New("config file has no lists")
+	}
+	lists := make([]syncListOptions, 0, len(configFile.Lists))
+	for i, list := range configFile.Lists {
+		name := list.Name
+		if name == "" {
+			name = list.ID
+		}
+		if name == "" {
+			return nil, fmt.Errorf("list %d: either name or id must be provided", i+1)
+		}
+		options := list.options(defaults)
+		if err := validateSyncListOptions(options); err != nil {
+			return nil, fmt.Errorf("list %s: %w", name, err)
+		}
+		lists = append(lists, options)
+	}
+	return lists, nil
+}
+
+// options merges the settings of the list over the defaults.
+func (list *SyncListConfig) options(defaults syncListOptions) syncListOptions {
+	options := defaults
+	options.ListName = list.Name
+	options.ListID = list.ID
+	options.Sources = list.Sources
+	if list.Description != "" {
+		options.Description = list.Description
+	}
+	if list.Kind != "" {
+		options.Kind = list.Kind
+	}
+	if list.IPVersion != "" {
+		options.IPVersion = list.IPVersion
+	}
+	if list.Comment != "" {
+		options.Comment = list.Comment
+	}
+	if list.NoComment != nil {
+		options.NoComment = *list.NoComment
+	}
+	if list.Mode != "" {
+		options.Mode = list.Mode
+	}
+	if list.Aggregate != nil {
+		options.Aggregate = *list.Aggregate
+	}
+	if list.SkipInvalid != nil {
+		options.SkipInvalid = *list.SkipInvalid
+	}
+	if list.Format != "" {
+		options.Format = list.Format
+	}
+	if len(list.JSONPaths) > 0 {
+		options.JSONPaths = list.JSONPaths
+	}
+	if list.CSVColumn != "" {
+		options.CSVColumn = list.CSVColumn
+	}
+	if list.MaxRemovals != "" {
+		options.MaxRemovals = list.MaxRemovals
+	}
+	if list.MinItems != nil {
+		options.MinItems = *list.MinItems
+	}
+	return options
+}
+
+// validateSyncListOptions checks the settings of a list from a config file the same way the flags are checked.
+func validateSyncListOptions(options syncListOptions) error {
+	if len(options.Sources) == 0 {
+		return errors.New("at least one source must be provided")
+	}
+	for _, source := range options.Sources {
+		_, source = splitSourceLabel(source)
+		if _, err := validateSource(source); err != nil {
+			return err
+		}
+	}
+	if !slices.Contains(validListKinds, options.Kind) {
+		return fmt.Errorf("invalid kind: %s. Valid kinds are: %s", options.Kind, strings.Join(validListKinds, ", "))
+	}
+	validVersions := []string{ipv4Flag, ipv6Flag, ipBothFlag}
+	if !slices.Contains(validVersions, options.IPVersion) {
+		return fmt.Errorf("invalid ip-version: %s. Valid versions are: %s", options.IPVersion, strings.Join(validVersions, ", "))
+	}
+	if !slices.Contains([]string{replaceSyncMode, diffSyncMode}, options.Mode) {
+		return fmt.Errorf("invalid mode: %s. Valid modes are: %s, %s", options.Mode, replaceSyncMode, diffSyncMode)
+	}
+	if !slices.Contains(validSourceFormats, options.Format) {
+		return fmt.Errorf("invalid format: %s. Valid formats are: %s", options.Format, strings.Join(validSourceFormats, ", "))
+	}
+	if options.MaxRemovals != "" {
+		if _, err := parseRemovalLimit(options.MaxRemovals); err != nil {
+			return err
+		}
+	}
+	if options.MinItems < 0 {
+		return fmt.Errorf("%s cannot be negative", minItemsFlag)
+	}
+	if len(options.Comment) > 64 {
+		return errors.New("comment cannot be longer than 64 characters")
+	}
+	return nil
+}
+
+// syncListsFromConfig syncs every list in the config file.
+// A list that fails does not stop the other lists, and the result of every list is printed at the end.
+func syncListsFromConfig(ctx context.Context, c *cli.Command) error {
+	defaults := syncListOptionsFromFlags(c)
+	if defaults.ListName != "" || defaults.ListID != "" || len(defaults.Sources) > 0 {
+		return fmt.Errorf("--%s cannot be used with --list-name, --list-id or sources", syncListConfigFlag)
+	}
+	lists, err := loadSyncListConfig(c.String(syncListConfigFlag), defaults)
+	if err != nil {
+		return err
+	}
+
+	results := make([]syncListResult, 0, len(lists))
+	failed := 0
+	for _, options := range lists {
+		name := options.ListName
+		if options.ListID != "" {
+			name = options.ListID
+		}
+		logger.Infof("Syncing list %s", name)
+		items, err := syncList(ctx, c, options)
+		if err != nil {
+			logger.WithError(err).Errorf("Error syncing list %s", name)
+			failed++
+		}
+		results = append(results, syncListResult{List: name, Kind: options.Kind, Items: items, Err: err})
+	}
+	printSyncListResults(os.Stdout, results)
+	if failed > 0 {
+		return fmt.Errorf("failed to sync %d of %d lists", failed, len(results))
+	}
+	return nil
+}
+
+// printSyncListResults prints a table with the result of every list.
+func printSyncListResults(w io.Writer, results []syncListResult) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "LIST\tKIND\tITEMS\tRESULT")
+	for _, result := range results {
+		status := "synced"
+		if result.Err != nil {
+			status = "error: " + result.Err.Error()
+		}
+		fmt.Fprintf(table, "%s\t%s\t%d\t%s\n", result.List, result.Kind, result.Items, status)
+	}
+	table.Flush()
+}
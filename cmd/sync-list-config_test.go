@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeSyncListConfig writes a config file to a temporary directory and returns its path.
+func writeSyncListConfig(t *testing.T, config string) string {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "lists.yml")
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+	return configPath
+}
+
+func Test_LoadSyncListConfig(t *testing.T) {
+	defaults := syncListOptions{Kind: "ip", IPVersion: ipBothFlag, Mode: replaceSyncMode, Format: textSourceFormat, Description: "Created by cloudflare-utils"}
+	configPath := writeSyncListConfig(t, `lists:
+  - name: allow
+    sources: ["preset://cloudflare"]
+    ip_version: ipv4
+    aggregate: true
+  - id: 5e8cd1c2a1f94d8fb0b2d5c1f3a4e6b7
+    kind: redirect
+    description: Marketing redirects
+    sources: ["file://redirects.csv"]
+    no_comment: true
+`)
+	lists, err := loadSyncListConfig(configPath, defaults)
+	if assert.NoError(t, err) && assert.Len(t, lists, 2) {
+		assert.Equal(t, "allow", lists[0].ListName)
+		assert.Equal(t, ipv4Flag, lists[0].IPVersion)
+		assert.True(t, lists[0].Aggregate)
+		assert.Equal(t, "Created by cloudflare-utils", lists[0].Description)
+		assert.Equal(t, "redirect", lists[1].Kind)
+		assert.Equal(t, "Marketing redirects", lists[1].Description)
+		assert.True(t, lists[1].NoComment)
+		assert.Equal(t, replaceSyncMode, lists[1].Mode, "Expected unset settings to use the defaults")
+	}
+
+	testCases := map[string]string{
+		"lists: []": "config file has no lists",
+		"lists:\n  - sources: [\"preset://cloudflare\"]":                                    "list 1: either name or id must be provided",
+		"lists:\n  - name: allow":                                                           "list allow: at least one source must be provided",
+		"lists:\n  - name: allow\n    sources: [\"ftp://a\"]":                               "list allow: invalid source scheme: ftp",
+		"lists:\n  - name: allow\n    kind: email\n    sources: [\"preset://cloudflare\"]":  "list allow: invalid kind: email. Valid kinds are: ip, hostname, asn, redirect",
+		"lists:\n  - name: allow\n    mode: merge\n    sources: [\"preset://cloudflare\"]":  "list allow: invalid mode: merge. Valid modes are: replace, diff",
+		"lists:\n  - name: allow\n    format: xml\n    sources: [\"preset://cloudflare\"]":  "list allow: invalid format: xml. Valid formats are: text, json, csv",
+		"lists:\n  - name: allow\n    ip_version: ipv5\n    sources: [\"preset://github\"]": "list allow: invalid ip-version: ipv5. Valid versions are: ipv4, ipv6, both",
+	}
+	for config, expected := range testCases {
+		_, err := loadSyncListConfig(writeSyncListConfig(t, config), defaults)
+		assert.EqualError(t, err, expected, config)
+	}
+}
+
+func Test_SyncList_Config(t *testing.T) {
+	ipFile, redirectFile := "test-config-ips.txt", "test-config-redirects.csv"
+	if err := os.WriteFile(ipFile, []byte("1.1.1.1\n2606:4700:4700::1111\n"), 0600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(ipFile)
+	if err := os.WriteFile(redirectFile, []byte("example.com/blog,https://example.com/news\n"), 0600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(redirectFile)
+
+	configPath := writeSyncListConfig(t, `lists:
+  - name: test-list
+    sources: ["file://`+ipFile+`"]
+    mode: diff
+  - name: test-redirects
+    kind: redirect
+    sources: ["file://`+redirectFile+`"]
+`)
+	err := withApp(t, []string{"cloudflare-utils", "sync-list", "--config", configPath, "--dry-run"})
+	assert.NoError(t, err, "Expected no error when syncing lists from a config file")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--config", configPath, "--list-name", "test-list"})
+	assert.EqualError(t, err, "--config cannot be used with --list-name, --list-id or sources")
+
+	failingPath := writeSyncListConfig(t, `lists:
+  - name: test-list
+    kind: redirect
+    sources: ["file://`+redirectFile+`"]
+  - name: test-redirects
+    kind: redirect
+    sources: ["file://`+redirectFile+`"]
+`)
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--config", failingPath, "--dry-run"})
+	assert.EqualError(t, err, "failed to sync 1 of 2 lists", "Expected a failing list to not stop the other lists")
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	syncModeFlag    = "mode"
+	replaceSyncMode = "replace"
+	diffSyncMode    = "diff"
+
+	// dryRunListID is the list ID used in dry runs when the list would be created.
+	dryRunListID = "dry-run-list-id"
+)
+
+// listDiff are the changes needed to make a list match the source.
+type listDiff struct {
+	ToAdd     []cloudflare.ListItemCreateRequest
+	ToDelete  []cloudflare.ListItem
+	Unchanged int
+}
+
+// normalizeListIP returns the form Cloudflare stores an IP or CIDR in, so the same address written differently is not synced twice.
+func normalizeListIP(value string) string {
+	value = strings.TrimSpace(value)
+	if prefix, err := parseListPrefix(value); err == nil {
+		return prefixString(prefix)
+	}
+	return strings.ToLower(value)
+}
+
+// diffListItems compares the items currently in a list against the items from the source.
+func diffListItems(current []cloudflare.ListItem, desired []cloudflare.ListItemCreateRequest) listDiff {
+	var diff listDiff
+	wanted := make(map[string]bool, len(desired))
+	for _, item := range desired {
+		wanted[listItemKey(item)] = true
+	}
+
+	existing := make(map[string]bool, len(current))
+	for _, item := range current {
+		key := listItemKey(listItemRequest(item))
+		if !wanted[key] || existing[key] {
+			diff.ToDelete = append(diff.ToDelete, item)
+			continue
+		}
+		existing[key] = true
+		diff.Unchanged++
+	}
+
+	for _, item := range desired {
+		key := listItemKey(item)
+		if existing[key] {
+			continue
+		}
+		// Mark as existing so duplicates in the source are only added once.
+		existing[key] = true
+		diff.ToAdd = append(diff.ToAdd, item)
+	}
+	return diff
+}
+
+// Print writes every change and a summary of the diff.
+func (d listDiff) Print(w io.Writer) {
+	added := make([]string, 0, len(d.ToAdd))
+	for _, item := range d.ToAdd {
+		added = append(added, listItemValue(item))
+	}
+	slices.Sort(added)
+	for _, value := range added {
+		fmt.Fprintf(w, "  + %s\n", value)
+	}
+	removed := make([]string, 0, len(d.ToDelete))
+	for _, item := range d.ToDelete {
+		removed = append(removed, listItemValue(listItemRequest(item)))
+	}
+	slices.Sort(removed)
+	for _, value := range removed {
+		fmt.Fprintf(w, "  - %s\n", value)
+	}
+	fmt.Fprintf(w, "%d to add, %d to remove, %d unchanged.\n", len(d.ToAdd), len(d.ToDelete), d.Unchanged)
+}
+
+// syncListDiff only adds the items missing from the list and removes the items no longer in the source.
+// Items that are already in the list keep their comment and creation time.
+func syncListDiff(ctx context.Context, c *cli.Command, options syncListOptions, listID string, listItems []cloudflare.ListItemCreateRequest) error {
+	var current []cloudflare.ListItem
+	if listID != dryRunListID {
+		var err error
+		current, err = APIClient.ListListItems(ctx, accountRC, cloudflare.ListListItemsParams{ID: listID})
+		if err != nil {
+			return fmt.Errorf("error getting current list items: %w", err)
+		}
+	}
+	diff := diffListItems(current, listItems)
+	if len(diff.ToAdd) == 0 && len(diff.ToDelete) == 0 {
+		fmt.Printf("List ID %s is already in sync with %d items\n", listID, diff.Unchanged)
+		return nil
+	}
+	diff.Print(os.Stdout)
+	if err := checkListRemovals(c, options, len(current), diff.ToDelete); err != nil {
+		return err
+	}
+	if c.Bool(dryRunFlag) {
+		return nil
+	}
+
+	// Items are added before old items are removed so the list is never missing an item that is in both.
+	// A list can only have one bulk operation running, so the add has to complete before the delete starts.
+	noWait := c.Bool("no-wait")
+	if len(diff.ToAdd) > 0 {
+		resp, err := APIClient.CreateListItemsAsync(ctx, accountRC, cloudflare.ListCreateItemsParams{ID: listID, Items: diff.ToAdd})
+		if err != nil {
+			return fmt.Errorf("error adding list items: %w", err)
+		}
+		if err := waitListOperation(ctx, c, resp.Result.OperationID, "add list items", noWait && len(diff.ToDelete) == 0); err != nil {
+			return err
+		}
+	}
+	if len(diff.ToDelete) > 0 {
+		deleteItems := make([]cloudflare.ListItemDeleteItemRequest, 0, len(diff.ToDelete))
+		for _, item := range diff.ToDelete {
+			deleteItems = append(deleteItems, cloudflare.ListItemDeleteItemRequest{ID: item.ID})
+		}
+		resp, err := APIClient.DeleteListItemsAsync(ctx, accountRC, cloudflare.ListDeleteItemsParams{
+			ID:    listID,
+			Items: cloudflare.ListItemDeleteRequest{Items: deleteItems},
+		})
+		if err != nil {
+			return fmt.Errorf("error removing list items: %w", err)
+		}
+		if err := waitListOperation(ctx, c, resp.Result.OperationID, "remove list items", noWait); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Successfully added %d and removed %d items in list ID %s\n", len(diff.ToAdd), len(diff.ToDelete), listID)
+	return nil
+}
+
+// waitListOperation polls a list bulk operation until it completes or --timeout passes, unless noWait is set.
+func waitListOperation(ctx context.Context, c *cli.Command, operationID, action string, noWait bool) error {
+	if noWait {
+		fmt.Printf("Started async operation to %s. Operation ID: %s\n", action, operationID)
+		return nil
+	}
+	logger.Infof("Started async operation to %s. Operation ID: %s", action, operationID)
+	if err := PollListBulkOperation(ctx, accountRC, operationID, c.Duration(listTimeoutFlag)); err != nil {
+		return fmt.Errorf("error polling list bulk operation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NormalizeListIP(t *testing.T) {
+	testCases := map[string]string{
+		"1.2.3.4":            "1.2.3.4",
+		"1.2.3.4/32":         "1.2.3.4",
+		"10.1.2.3/8":         "10.0.0.0/8",
+		"2001:DB8::1":        "2001:db8::1",
+		"2001:db8:0:0::/64":  "2001:db8::/64",
+		"2001:db8::1/128":    "2001:db8::1",
+		" not-an-ip ":        "not-an-ip",
+		"2001:0db8::0:1/128": "2001:db8::1",
+	}
+	for input, expected := range testCases {
+		assert.Equal(t, expected, normalizeListIP(input), input)
+	}
+}
+
+func Test_DiffListItems(t *testing.T) {
+	current := []cloudflare.ListItem{
+		{ID: "1", IP: cloudflare.StringPtr("1.2.3.4"), Comment: "keep me"},
+		{ID: "2", IP: cloudflare.StringPtr("198.51.100.0/24")},
+		{ID: "3", IP: cloudflare.StringPtr("2001:db8::1")},
+		{ID: "4", IP: cloudflare.StringPtr("2001:db8::1")},
+	}
+	desired := []cloudflare.ListItemCreateRequest{
+		{IP: cloudflare.StringPtr("1.2.3.4/32")},
+		{IP: cloudflare.StringPtr("2001:DB8::1")},
+		{IP: cloudflare.StringPtr("203.0.113.0/24")},
+		{IP: cloudflare.StringPtr("203.0.113.0/24")},
+	}
+	diff := diffListItems(current, desired)
+	assert.Equal(t, 2, diff.Unchanged, "Expected items written differently to match")
+	if assert.Len(t, diff.ToAdd, 1, "Expected duplicates in the source to be added once") {
+		assert.Equal(t, "203.0.113.0/24", *diff.ToAdd[0].IP)
+	}
+	if assert.Len(t, diff.ToDelete, 2, "Expected removed and duplicate items to be deleted") {
+		assert.Equal(t, "2", diff.ToDelete[0].ID)
+		assert.Equal(t, "4", diff.ToDelete[1].ID)
+	}
+
+	var output bytes.Buffer
+	diff.Print(&output)
+	assert.Equal(t, "  + 203.0.113.0/24\n  - 198.51.100.0/24\n  - 2001:db8::1\n1 to add, 2 to remove, 2 unchanged.\n", output.String())
+}
+
+func Test_SyncList_Diff(t *testing.T) {
+	fileName := "test-diff-ips.txt"
+	err := os.WriteFile(fileName, []byte("1.2.3.4\n2001:db8::1\n"), 0600)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(fileName)
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--mode", "diff", "--dry-run", "--source", "file://" + fileName})
+	assert.NoError(t, err, "Expected no error when dry-running a diff sync")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--mode", "diff", "--source", "file://" + fileName})
+	assert.NoError(t, err, "Expected no error when running a diff sync")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--mode", "merge", "--source", "file://" + fileName})
+	assert.EqualError(t, err, "invalid mode: merge. Valid modes are: replace, diff")
+}
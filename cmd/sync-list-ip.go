@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+const (
+	aggregateFlag   = "aggregate"
+	skipInvalidFlag = "skip-invalid"
+
+	// Cloudflare lists only allow IPv4 prefixes from /8 and IPv6 prefixes from /12 to /64.
+	minIPv4ListBits = 8
+	minIPv6ListBits = 12
+	maxIPv6ListBits = 64
+)
+
+// parseListPrefix parses an IP or CIDR and clears the host bits.
+func parseListPrefix(value string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil || addr.Zone() != "" {
+		return netip.Prefix{}, fmt.Errorf("invalid IP or CIDR: %q", value)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// prefixString returns a prefix the way Cloudflare stores it, with single IPs written without a prefix length.
+func prefixString(prefix netip.Prefix) string {
+	if prefix.IsSingleIP() {
+		return prefix.Addr().String()
+	}
+	return prefix.String()
+}
+
+// parseIPItem parses an IP or CIDR that Cloudflare allows in a list.
+func parseIPItem(entry string) (cloudflare.ListItemCreateRequest, error) {
+	prefix, err := parseListPrefix(entry)
+	if err != nil {
+		return cloudflare.ListItemCreateRequest{}, err
+	}
+	bits := prefix.Bits()
+	if prefix.Addr().Is4() && bits < minIPv4ListBits {
+		return cloudflare.ListItemCreateRequest{}, fmt.Errorf("invalid IP or CIDR: %q. IPv4 prefixes must be /%d or longer", entry, minIPv4ListBits)
+	}
+	if prefix.Addr().Is6() && (bits < minIPv6ListBits || (bits > maxIPv6ListBits && !prefix.IsSingleIP())) {
+		return cloudflare.ListItemCreateRequest{}, fmt.Errorf("invalid IP or CIDR: %q. IPv6 prefixes must be between /%d and /%d or a single IP", entry, minIPv6ListBits, maxIPv6ListBits)
+	}
+	return cloudflare.ListItemCreateRequest{IP: cloudflare.StringPtr(prefixString(prefix))}, nil
+}
+
+// getFilteredIPs removes the IPs that are not the requested IP version.
+// If aggregate is set, overlapping and adjacent prefixes are collapsed into the fewest prefixes that cover the same IPs.
+func getFilteredIPs(listItems []cloudflare.ListItemCreateRequest, options syncListOptions) []cloudflare.ListItemCreateRequest {
+	ipVersion := options.IPVersion
+	comments := make(map[netip.Prefix]string, len(listItems))
+	prefixes := make([]netip.Prefix, 0, len(listItems))
+	filtered := make([]cloudflare.ListItemCreateRequest, 0, len(listItems))
+	for _, item := range listItems {
+		// Items have already been validated by parseIPItem.
+		prefix, _ := parseListPrefix(*item.IP)
+		if (ipVersion == ipv4Flag && !prefix.Addr().Is4()) || (ipVersion == ipv6Flag && !prefix.Addr().Is6()) {
+			continue
+		}
+		comments[prefix] = item.Comment
+		prefixes = append(prefixes, prefix)
+		filtered = append(filtered, item)
+	}
+	if len(filtered) < len(listItems) {
+		logger.Infof("Removed %d IPs that are not %s", len(listItems)-len(filtered), ipVersion)
+	}
+	if !options.Aggregate {
+		return filtered
+	}
+
+	aggregated := aggregatePrefixes(prefixes)
+	logger.Infof("Aggregated %d prefixes into %d", len(prefixes), len(aggregated))
+	listItems = make([]cloudflare.ListItemCreateRequest, 0, len(aggregated))
+	for _, prefix := range aggregated {
+		// Prefixes that were merged get the default comment, since the comments of the original prefixes may differ.
+		comment, ok := comments[prefix]
+		if !ok {
+			comment = listItemComment(options)
+		}
+		listItems = append(listItems, cloudflare.ListItemCreateRequest{IP: cloudflare.StringPtr(prefixString(prefix)), Comment: comment})
+	}
+	return listItems
+}
+
+// aggregatePrefixes returns the fewest prefixes that cover the same IPs.
+// Prefixes contained in another prefix are removed and sibling prefixes are merged into their parent,
+// as long as the parent is still allowed in a Cloudflare list.
+func aggregatePrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	sorted := slices.Clone(prefixes)
+	slices.SortFunc(sorted, func(a, b netip.Prefix) int {
+		if cmp := a.Addr().Compare(b.Addr()); cmp != 0 {
+			return cmp
+		}
+		return a.Bits() - b.Bits()
+	})
+
+	aggregated := make([]netip.Prefix, 0, len(sorted))
+	for _, prefix := range sorted {
+		// Sorting puts a prefix right after any prefix that contains it.
+		if len(aggregated) > 0 && aggregated[len(aggregated)-1].Overlaps(prefix) {
+			continue
+		}
+		aggregated = append(aggregated, prefix)
+		// Merging two siblings can make the parent a sibling of the previous prefix, so keep merging.
+		for len(aggregated) > 1 {
+			last, previous := aggregated[len(aggregated)-1], aggregated[len(aggregated)-2]
+			parent, ok := prefixParent(previous)
+			if !ok || previous.Bits() != last.Bits() || !parent.Contains(last.Addr()) {
+				break
+			}
+			aggregated = append(aggregated[:len(aggregated)-2], parent)
+		}
+	}
+	return aggregated
+}
+
+// prefixParent returns the prefix one bit shorter, if it is still allowed in a Cloudflare list.
+func prefixParent(prefix netip.Prefix) (netip.Prefix, bool) {
+	minBits := minIPv4ListBits
+	if prefix.Addr().Is6() {
+		minBits = minIPv6ListBits
+	}
+	if prefix.Bits() <= minBits || (prefix.Addr().Is6() && prefix.Bits()-1 > maxIPv6ListBits) {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(prefix.Addr(), prefix.Bits()-1).Masked(), true
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"net/netip"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseIPItem(t *testing.T) {
+	testCases := map[string]string{
+		"1.2.3.4":          "1.2.3.4",
+		"1.2.3.4/32":       "1.2.3.4",
+		"10.1.2.3/8":       "10.0.0.0/8",
+		"::ffff:192.0.2.1": "192.0.2.1",
+		"2001:DB8::1/48":   "2001:db8::/48",
+		"2001:db8::1/128":  "2001:db8::1",
+	}
+	for input, expected := range testCases {
+		item, err := parseIPItem(input)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, expected, *item.IP, input)
+		}
+	}
+
+	invalid := map[string]string{
+		"# comment":       `invalid IP or CIDR: "# comment"`,
+		"1.2.3":           `invalid IP or CIDR: "1.2.3"`,
+		"fe80::1%eth0":    `invalid IP or CIDR: "fe80::1%eth0"`,
+		"10.0.0.0/7":      `invalid IP or CIDR: "10.0.0.0/7". IPv4 prefixes must be /8 or longer`,
+		"2001:db8::/96":   `invalid IP or CIDR: "2001:db8::/96". IPv6 prefixes must be between /12 and /64 or a single IP`,
+		"2000::/8":        `invalid IP or CIDR: "2000::/8". IPv6 prefixes must be between /12 and /64 or a single IP`,
+		"1.2.3.4/33":      `invalid IP or CIDR: "1.2.3.4/33"`,
+		"1.2.3.4 # allow": `invalid IP or CIDR: "1.2.3.4 # allow"`,
+	}
+	for input, expected := range invalid {
+		_, err := parseIPItem(input)
+		assert.EqualError(t, err, expected, input)
+	}
+}
+
+func Test_AggregatePrefixes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    []string
+		expected []string
+	}{
+		{
+			name:     "Adjacent siblings",
+			input:    []string{"192.0.2.0/25", "192.0.2.128/25"},
+			expected: []string{"192.0.2.0/24"},
+		},
+		{
+			name:     "Repeated merging",
+			input:    []string{"198.51.100.3/32", "198.51.100.0/31", "198.51.100.2/32"},
+			expected: []string{"198.51.100.0/30"},
+		},
+		{
+			name:     "Contained prefixes",
+			input:    []string{"10.1.2.3/32", "10.0.0.0/8", "10.200.0.0/16"},
+			expected: []string{"10.0.0.0/8"},
+		},
+		{
+			name:     "Adjacent but not siblings",
+			input:    []string{"192.0.2.128/25", "192.0.3.0/25"},
+			expected: []string{"192.0.2.128/25", "192.0.3.0/25"},
+		},
+		{
+			name:     "Not merged past the list minimum",
+			input:    []string{"10.0.0.0/8", "11.0.0.0/8"},
+			expected: []string{"10.0.0.0/8", "11.0.0.0/8"},
+		},
+		{
+			name:     "IPv6 single IPs are not merged into a /127",
+			input:    []string{"2001:db8::/128", "2001:db8::1/128", "2001:db8:0:1::/64", "2001:db8::/64"},
+			expected: []string{"2001:db8::/63"},
+		},
+		{
+			name:     "Mixed families",
+			input:    []string{"2001:db8::/64", "192.0.2.0/24", "2001:db8::1/128"},
+			expected: []string{"192.0.2.0/24", "2001:db8::/64"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prefixes := make([]netip.Prefix, 0, len(tc.input))
+			for _, value := range tc.input {
+				prefixes = append(prefixes, netip.MustParsePrefix(value))
+			}
+			actual := make([]string, 0, len(tc.expected))
+			for _, prefix := range aggregatePrefixes(prefixes) {
+				actual = append(actual, prefix.String())
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func Test_SyncList_InvalidIPs(t *testing.T) {
+	fileName := "test-invalid-ips.txt"
+	err := os.WriteFile(fileName, []byte("1.2.3.4\n\nnot-an-ip\n192.0.2.0/25\n192.0.2.128/25\n1.2.3.4/32\n10.0.0.0/7\n"), 0600)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(fileName)
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--source", "file://" + fileName})
+	assert.EqualError(t, err, "error parsing ip list items: line 3: invalid IP or CIDR: \"not-an-ip\"\nline 7: invalid IP or CIDR: \"10.0.0.0/7\". IPv4 prefixes must be /8 or longer")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--skip-invalid", "--aggregate", "--mode", "diff", "--source", "file://" + fileName})
+	assert.NoError(t, err, "Expected invalid IPs to be skipped")
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+const listKindFlag = "kind"
+
+var (
+	validListKinds = []string{cloudflare.ListTypeIP, cloudflare.ListTypeHostname, cloudflare.ListTypeASN, cloudflare.ListTypeRedirect}
+	// validRedirectStatusCodes are the status codes Cloudflare allows for bulk redirects.
+	validRedirectStatusCodes = []int{301, 302, 307, 308}
+)
+
+// parseListItems converts the entries read from a source into items of the given list kind.
+// Every invalid entry is reported with its line number, unless skip invalid is set.
+// Duplicate entries are removed, and the comments of duplicates from different sources are merged.
+func parseListItems(entries []sourceEntry, options syncListOptions) ([]cloudflare.ListItemCreateRequest, error) {
+	kind := options.Kind
+	var parse func(string) (cloudflare.ListItemCreateRequest, error)
+	switch kind {
+	case cloudflare.ListTypeIP:
+		parse = parseIPItem
+	case cloudflare.ListTypeHostname:
+		parse = parseHostnameItem
+	case cloudflare.ListTypeASN:
+		parse = parseASNItem
+	case cloudflare.ListTypeRedirect:
+		parse = parseRedirectItem
+	default:
+		return nil, fmt.Errorf("invalid list kind: %s", kind)
+	}
+
+	comment := listItemComment(options)
+	listItems := make([]cloudflare.ListItemCreateRequest, 0, len(entries))
+	seen := make(map[string]int, len(entries))
+	duplicates := 0
+	var invalid []error
+	for i, entry := range entries {
+		// Allow redirect CSV files exported from the dashboard, which start with a header row.
+		if i == 0 && kind == cloudflare.ListTypeRedirect && strings.HasPrefix(strings.ToLower(entry.Value), "source_url") {
+			continue
+		}
+		item, err := parse(entry.Value)
+		if err != nil {
+			location := fmt.Sprintf("line %d", entry.Line)
+			if entry.Source != "" {
+				location = entry.Source + " " + location
+			}
+			invalid = append(invalid, fmt.Errorf("%s: %w", location, err))
+			continue
+		}
+		item.Comment = comment
+		if entry.Comment != "" && !options.NoComment {
+			item.Comment = entry.Comment
+		}
+		key := listItemKey(item)
+		if index, ok := seen[key]; ok {
+			listItems[index].Comment = mergeItemComments(listItems[index].Comment, item.Comment)
+			duplicates++
+			continue
+		}
+		seen[key] = len(listItems)
+		listItems = append(listItems, item)
+	}
+	if duplicates > 0 {
+		logger.Infof("Removed %d duplicate items", duplicates)
+	}
+	if len(invalid) > 0 {
+		if !options.SkipInvalid {
+			return nil, errors.Join(invalid...)
+		}
+		for _, err := range invalid {
+			logger.Warnf("Skipping invalid item: %s", err)
+		}
+	}
+	if kind == cloudflare.ListTypeIP {
+		listItems = getFilteredIPs(listItems, options)
+	}
+	return listItems, nil
+}
+
+// mergeItemComments combines the comments of an item that is in more than one source.
+func mergeItemComments(existing, comment string) string {
+	if existing == "" {
+		return comment
+	}
+	if comment == "" || slices.Contains(strings.Split(existing, ", "), comment) {
+		return existing
+	}
+	return existing + ", " + comment
+}
+
+// parseHostnameItem parses a hostname such as example.com or *.example.com.
+func parseHostnameItem(entry string) (cloudflare.ListItemCreateRequest, error) {
+	hostname := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(entry)), ".")
+	if hostname == "" || strings.ContainsAny(hostname, "/:@? ") || strings.Contains(strings.TrimPrefix(hostname, "*."), "*") {
+		return cloudflare.ListItemCreateRequest{}, fmt.Errorf("invalid hostname: %q", entry)
+	}
+	return cloudflare.ListItemCreateRequest{Hostname: &cloudflare.Hostname{UrlHostname: hostname}}, nil
+}
+
+// parseASNItem parses an ASN with or without the AS prefix, such as AS13335 or 13335.
+func parseASNItem(entry string) (cloudflare.ListItemCreateRequest, error) {
+	value := strings.TrimSpace(entry)
+	if len(value) > 2 && strings.EqualFold(value[:2], "as") {
+		value = value[2:]
+	}
+	asn, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || asn == 0 {
+		return cloudflare.ListItemCreateRequest{}, fmt.Errorf("invalid ASN: %q", entry)
+	}
+	asn32 := uint32(asn)
+	return cloudflare.ListItemCreateRequest{ASN: &asn32}, nil
+}
+
+// parseRedirectItem parses a CSV row in the same format as the dashboard's bulk redirect import:
+// source_url,target_url,status_code,preserve_query_string,include_subdomains,subpath_matching,preserve_path_suffix
+// Only source_url and target_url are required.
+func parseRedirectItem(entry string) (cloudflare.ListItemCreateRequest, error) {
+	reader := csv.NewReader(strings.NewReader(entry))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	fields, err := reader.Read()
+	if err != nil {
+		return cloudflare.ListItemCreateRequest{}, fmt.Errorf("invalid redirect %q: %w", entry, err)
+	}
+	if len(fields) < 2 || len(fields) > 7 {
+		return cloudflare.ListItemCreateRequest{}, fmt.Errorf("invalid redirect %q: expected between 2 and 7 columns, got %d", entry, len(fields))
+	}
+	redirect := &cloudflare.Redirect{
+		SourceUrl: strings.TrimSpace(fields[0]),
+		TargetUrl: strings.TrimSpace(fields[1]),
+	}
+	if redirect.SourceUrl == "" || redirect.TargetUrl == "" {
+		return cloudflare.ListItemCreateRequest{}, fmt.Errorf("invalid redirect %q: source and target URL are required", entry)
+	}
+	if len(fields) > 2 && strings.TrimSpace(fields[2]) != "" {
+		statusCode, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+		if err != nil || !slices.Contains(validRedirectStatusCodes, statusCode) {
+			return cloudflare.ListItemCreateRequest{}, fmt.Errorf("invalid redirect %q: status code must be one of 301, 302, 307 or 308", entry)
+		}
+		redirect.StatusCode = &statusCode
+	}
+	options := []**bool{&redirect.PreserveQueryString, &redirect.IncludeSubdomains, &redirect.SubpathMatching, &redirect.PreservePathSuffix}
+	for i, option := range options {
+		if len(fields) <= i+3 || strings.TrimSpace(fields[i+3]) == "" {
+			continue
+		}
+		value, err := strconv.ParseBool(strings.TrimSpace(fields[i+3]))
+		if err != nil {
+			return cloudflare.ListItemCreateRequest{}, fmt.Errorf("invalid redirect %q: column %d must be true or false", entry, i+4)
+		}
+		*option = &value
+	}
+	return cloudflare.ListItemCreateRequest{Redirect: redirect}, nil
+}
+
+// listItemKey returns a key that is the same for items that Cloudflare considers equal, regardless of the list kind.
+func listItemKey(item cloudflare.ListItemCreateRequest) string {
+	switch {
+	case item.IP != nil:
+		return normalizeListIP(*item.IP)
+	case item.Hostname != nil:
+		return strings.TrimSuffix(strings.ToLower(item.Hostname.UrlHostname), ".")
+	case item.ASN != nil:
+		return strconv.FormatUint(uint64(*item.ASN), 10)
+	case item.Redirect != nil:
+		// Every field of a redirect matters, so a redirect with a new target is replaced.
+		// Unset fields are compared as the defaults Cloudflare fills in.
+		redirect := item.Redirect
+		statusCode := 301
+		if redirect.StatusCode != nil {
+			statusCode = *redirect.StatusCode
+		}
+		return fmt.Sprintf("%s|%s|%d|%t|%t|%t|%t", redirect.SourceUrl, redirect.TargetUrl, statusCode,
+			cloudflare.Bool(redirect.PreserveQueryString), cloudflare.Bool(redirect.IncludeSubdomains),
+			cloudflare.Bool(redirect.SubpathMatching), cloudflare.Bool(redirect.PreservePathSuffix))
+	}
+	return ""
+}
+
+// listItemValue returns the value of an item for printing.
+func listItemValue(item cloudflare.ListItemCreateRequest) string {
+	switch {
+	case item.IP != nil:
+		return *item.IP
+	case item.Hostname != nil:
+		return item.Hostname.UrlHostname
+	case item.ASN != nil:
+		return "AS" + strconv.FormatUint(uint64(*item.ASN), 10)
+	case item.Redirect != nil:
+		value := item.Redirect.SourceUrl + " -> " + item.Redirect.TargetUrl
+		if item.Redirect.StatusCode != nil {
+			value += fmt.Sprintf(" (%d)", *item.Redirect.StatusCode)
+		}
+		return value
+	}
+	return ""
+}
+
+// listItemSourceValue returns the value of an item the way it is written in a source, so exported lists can be synced again.
+// Redirects are written as a CSV row with every column that is set.
+func listItemSourceValue(item cloudflare.ListItemCreateRequest) string {
+	if item.Redirect == nil {
+		return listItemValue(item)
+	}
+	redirect := item.Redirect
+	fields := []string{redirect.SourceUrl, redirect.TargetUrl, ""}
+	if redirect.StatusCode != nil {
+		fields[2] = strconv.Itoa(*redirect.StatusCode)
+	}
+	for _, option := range []*bool{redirect.PreserveQueryString, redirect.IncludeSubdomains, redirect.SubpathMatching, redirect.PreservePathSuffix} {
+		value := ""
+		if option != nil {
+			value = strconv.FormatBool(*option)
+		}
+		fields = append(fields, value)
+	}
+	var row strings.Builder
+	writer := csv.NewWriter(&row)
+	// Writing to a strings.Builder cannot fail.
+	_ = writer.Write(fields)
+	writer.Flush()
+	return strings.TrimSuffix(row.String(), "\n")
+}
+
+// listItemRequest returns the create request of an item that is already in a list.
+func listItemRequest(item cloudflare.ListItem) cloudflare.ListItemCreateRequest {
+	return cloudflare.ListItemCreateRequest{
+		IP:       item.IP,
+		Redirect: item.Redirect,
+		Hostname: item.Hostname,
+		ASN:      item.ASN,
+		Comment:  item.Comment,
+	}
+}
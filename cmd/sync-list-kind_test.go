@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseHostnameItem(t *testing.T) {
+	testCases := map[string]string{
+		"Example.com":    "example.com",
+		"*.example.com.": "*.example.com",
+		" api.example ":  "api.example",
+	}
+	for input, expected := range testCases {
+		item, err := parseHostnameItem(input)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, expected, item.Hostname.UrlHostname)
+		}
+	}
+	for _, input := range []string{"https://example.com", "example.com/path", "a.*.example.com", ""} {
+		_, err := parseHostnameItem(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func Test_ParseASNItem(t *testing.T) {
+	for _, input := range []string{"AS13335", "as13335", "13335"} {
+		item, err := parseASNItem(input)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, uint32(13335), *item.ASN)
+		}
+	}
+	for _, input := range []string{"AS", "0", "-1", "4294967296", "ASN13335"} {
+		_, err := parseASNItem(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func Test_ParseRedirectItem(t *testing.T) {
+	item, err := parseRedirectItem("example.com/blog, https://example.com/news")
+	if assert.NoError(t, err) {
+		assert.Equal(t, &cloudflare.Redirect{SourceUrl: "example.com/blog", TargetUrl: "https://example.com/news"}, item.Redirect)
+	}
+
+	item, err = parseRedirectItem(`example.com/a,"https://example.com/b?x=1,2",308,true,,false,true`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "https://example.com/b?x=1,2", item.Redirect.TargetUrl)
+		assert.Equal(t, 308, *item.Redirect.StatusCode)
+		assert.True(t, *item.Redirect.PreserveQueryString)
+		assert.Nil(t, item.Redirect.IncludeSubdomains, "Expected empty columns to be left unset")
+		assert.False(t, *item.Redirect.SubpathMatching)
+		assert.True(t, *item.Redirect.PreservePathSuffix)
+	}
+
+	testCases := map[string]string{
+		"example.com/a":                          `invalid redirect "example.com/a": expected between 2 and 7 columns, got 1`,
+		"example.com/a,":                         `invalid redirect "example.com/a,": source and target URL are required`,
+		"example.com/a,https://example.com,200":  `invalid redirect "example.com/a,https://example.com,200": status code must be one of 301, 302, 307 or 308`,
+		"example.com/a,https://example.com,,yes": `invalid redirect "example.com/a,https://example.com,,yes": column 4 must be true or false`,
+	}
+	for input, expected := range testCases {
+		_, err := parseRedirectItem(input)
+		assert.EqualError(t, err, expected, input)
+	}
+}
+
+func Test_ListItemKey(t *testing.T) {
+	statusCode := 301
+	withDefaults := cloudflare.ListItemCreateRequest{Redirect: &cloudflare.Redirect{
+		SourceUrl: "example.com/blog", TargetUrl: "https://example.com/news", StatusCode: &statusCode, IncludeSubdomains: cloudflare.BoolPtr(false),
+	}}
+	unset := cloudflare.ListItemCreateRequest{Redirect: &cloudflare.Redirect{SourceUrl: "example.com/blog", TargetUrl: "https://example.com/news"}}
+	assert.Equal(t, listItemKey(withDefaults), listItemKey(unset), "Expected unset redirect fields to match the defaults")
+
+	moved := cloudflare.ListItemCreateRequest{Redirect: &cloudflare.Redirect{SourceUrl: "example.com/blog", TargetUrl: "https://example.com/articles"}}
+	assert.NotEqual(t, listItemKey(unset), listItemKey(moved), "Expected a new target to be a different item")
+
+	asn := uint32(13335)
+	assert.Equal(t, "AS13335", listItemValue(cloudflare.ListItemCreateRequest{ASN: &asn}))
+	assert.Equal(t, "example.com/blog -> https://example.com/news (301)", listItemValue(withDefaults))
+}
+
+func Test_SyncList_Kinds(t *testing.T) {
+	fileName := "test-redirects.csv"
+	err := os.WriteFile(fileName, []byte("source_url,target_url,status_code\nexample.com/blog,https://example.com/news,301\nexample.com/docs,https://docs.example.com,302\n"), 0600)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(fileName)
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-redirects", "--kind", "redirect", "--source", "file://" + fileName})
+	assert.NoError(t, err, "Expected no error when syncing a redirect list")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-redirects", "--kind", "redirect", "--mode", "diff", "--source", "file://" + fileName})
+	assert.NoError(t, err, "Expected no error when diff syncing a redirect list")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--kind", "redirect", "--source", "file://" + fileName})
+	assert.EqualError(t, err, "error getting Cloudflare list: list test-list has kind ip, not redirect")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-redirects", "--kind", "asn", "--source", "file://" + fileName})
+	assert.ErrorContains(t, err, `error parsing asn list items: line 1: invalid ASN: "source_url,target_url,status_code"`)
+	assert.ErrorContains(t, err, `line 3: invalid ASN: "example.com/docs,https://docs.example.com,302"`)
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-hosts", "--kind", "hostname", "--source", "preset://cloudflare"})
+	assert.EqualError(t, err, "presets can only be used with ip lists")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--kind", "email", "--source", "file://" + fileName})
+	assert.EqualError(t, err, "invalid kind: email. Valid kinds are: ip, hostname, asn, redirect")
+}
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// presetURLs are the feeds used by the presets. They are variables so tests can serve recorded responses.
+var presetURLs = map[string]string{
+	"uptime-robot":   "https://cdn.uptimerobot.com/api/IPv4andIPv6.txt",
+	"aws":            "https://ip-ranges.amazonaws.com/ip-ranges.json",
+	"gcp":            "https://www.gstatic.com/ipranges/cloud.json",
+	"fastly":         "https://api.fastly.com/public-ip-list",
+	"pingdom-ipv4":   "https://my.pingdom.com/probes/ipv4",
+	"pingdom-ipv6":   "https://my.pingdom.com/probes/ipv6",
+	"stripe-webhook": "https://stripe.com/files/ips/ips_webhooks.json",
+	"stripe-api":     "https://stripe.com/files/ips/ips_api.json",
+	"atlassian":      "https://ip-ranges.atlassian.com/",
+	"tor":            "https://check.torproject.org/torbulkexitlist",
+}
+
+// fetchJSON fetches a preset feed and decodes it into v.
+func fetchJSON(ctx context.Context, url string, v any) error {
+	data, err := fetchURL(ctx, url)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error parsing response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// fetchLines fetches a plain text preset feed with one IP per line.
+func fetchLines(ctx context.Context, url string) ([]string, error) {
+	data, err := fetchURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	entries := splitSourceLines(string(data))
+	ips := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ips = append(ips, entry.Value)
+	}
+	return ips, nil
+}
+
+// matchesInclude checks if any of the values are included. Everything matches if nothing is included.
+func matchesInclude(include map[string]bool, values ...string) bool {
+	if len(include) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(values, func(value string) bool {
+		return include[strings.ToLower(value)]
+	})
+}
+
+// getAWSIPs returns the ranges in the AWS ip-ranges.json.
+// ?include= filters by service, such as cloudfront, and ?region= filters by region, such as us-east-1.
+func getAWSIPs(ctx context.Context, query url.Values) ([]string, error) {
+	var ranges struct {
+		Prefixes []struct {
+			IPPrefix string `json:"ip_prefix"`
+			Region   string `json:"region"`
+			Service  string `json:"service"`
+		} `json:"prefixes"`
+		IPv6Prefixes []struct {
+			IPv6Prefix string `json:"ipv6_prefix"`
+			Region     string `json:"region"`
+			Service    string `json:"service"`
+		} `json:"ipv6_prefixes"`
+	}
+	if err := fetchJSON(ctx, presetURLs["aws"], &ranges); err != nil {
+		return nil, err
+	}
+	services := queryToList(query)
+	regions := queryKeyToList(query, "region")
+	var ips []string
+	for _, prefix := range ranges.Prefixes {
+		if matchesInclude(services, prefix.Service) && matchesInclude(regions, prefix.Region) {
+			ips = append(ips, prefix.IPPrefix)
+		}
+	}
+	for _, prefix := range ranges.IPv6Prefixes {
+		if matchesInclude(services, prefix.Service) && matchesInclude(regions, prefix.Region) {
+			ips = append(ips, prefix.IPv6Prefix)
+		}
+	}
+	return ips, nil
+}
+
+// getGoogleCloudIPs returns the Google Cloud ranges. ?include= filters by scope, such as us-central1.
+func getGoogleCloudIPs(ctx context.Context, query url.Values) ([]string, error) {
+	var ranges struct {
+		Prefixes []struct {
+			IPv4Prefix string `json:"ipv4Prefix"`
+			IPv6Prefix string `json:"ipv6Prefix"`
+			Scope      string `json:"scope"`
+		} `json:"prefixes"`
+	}
+	if err := fetchJSON(ctx, presetURLs["gcp"], &ranges); err != nil {
+		return nil, err
+	}
+	scopes := queryToList(query)
+	var ips []string
+	for _, prefix := range ranges.Prefixes {
+		if !matchesInclude(scopes, prefix.Scope) {
+			continue
+		}
+		if prefix.IPv4Prefix != "" {
+			ips = append(ips, prefix.IPv4Prefix)
+		}
+		if prefix.IPv6Prefix != "" {
+			ips = append(ips, prefix.IPv6Prefix)
+		}
+	}
+	return ips, nil
+}
+
+// getAzureIPs returns the ranges of service tags in an Azure service tags file.
+// Microsoft publishes the file under a new URL every week, so it is read from ?file= or ?url=.
+// ?include= is required and is the service tags to sync, such as AzureFrontDoor.Backend.
+func getAzureIPs(ctx context.Context, query url.Values) ([]string, error) {
+	include := queryToList(query)
+	if len(include) == 0 {
+		return nil, fmt.Errorf("azure preset requires ?include= with the service tags to sync")
+	}
+	var data []byte
+	var err error
+	switch {
+	case query.Get("file") != "":
+		data, err = os.ReadFile(query.Get("file"))
+	case query.Get("url") != "":
+		data, err = fetchURL(ctx, query.Get("url"))
+	default:
+		return nil, fmt.Errorf("azure preset requires ?file= or ?url= with the service tags file from https://www.microsoft.com/en-us/download/details.aspx?id=56519")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading Azure service tags: %w", err)
+	}
+	var serviceTags struct {
+		Values []struct {
+			Name       string `json:"name"`
+			Properties struct {
+				AddressPrefixes []string `json:"addressPrefixes"`
+			} `json:"properties"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(data, &serviceTags); err != nil {
+		return nil, fmt.Errorf("error parsing Azure service tags: %w", err)
+	}
+	var ips []string
+	for _, tag := range serviceTags.Values {
+		if include[strings.ToLower(tag.Name)] {
+			ips = append(ips, tag.Properties.AddressPrefixes...)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no Azure service tags matched %s", query.Get("include"))
+	}
+	return ips, nil
+}
+
+// getFastlyIPs returns the Fastly edge ranges.
+func getFastlyIPs(ctx context.Context) ([]string, error) {
+	var ranges struct {
+		Addresses     []string `json:"addresses"`
+		IPv6Addresses []string `json:"ipv6_addresses"`
+	}
+	if err := fetchJSON(ctx, presetURLs["fastly"], &ranges); err != nil {
+		return nil, err
+	}
+	return append(ranges.Addresses, ranges.IPv6Addresses...), nil
+}
+
+// getPingdomIPs returns the IPs of the Pingdom probe servers.
+func getPingdomIPs(ctx context.Context) ([]string, error) {
+	var ips []string
+	for _, feed := range []string{"pingdom-ipv4", "pingdom-ipv6"} {
+		feedIPs, err := fetchLines(ctx, presetURLs[feed])
+		if err != nil {
+			return nil, err
+		}
+		ips = append(ips, feedIPs...)
+	}
+	return ips, nil
+}
+
+// getStripeIPs returns the IPs Stripe sends webhooks from. ?include=api adds the IPs of the Stripe API.
+func getStripeIPs(ctx context.Context, query url.Values) ([]string, error) {
+	var webhooks struct {
+		Webhooks []string `json:"WEBHOOKS"`
+	}
+	if err := fetchJSON(ctx, presetURLs["stripe-webhook"], &webhooks); err != nil {
+		return nil, err
+	}
+	ips := webhooks.Webhooks
+	if queryToList(query)["api"] {
+		var api struct {
+			API []string `json:"API"`
+		}
+		if err := fetchJSON(ctx, presetURLs["stripe-api"], &api); err != nil {
+			return nil, err
+		}
+		ips = append(ips, api.API...)
+	}
+	return ips, nil
+}
+
+// getAtlassianIPs returns the Atlassian cloud ranges. ?include= filters by product, such as jira or bitbucket.
+func getAtlassianIPs(ctx context.Context, query url.Values) ([]string, error) {
+	var ranges struct {
+		Items []struct {
+			CIDR    string   `json:"cidr"`
+			Product []string `json:"product"`
+		} `json:"items"`
+	}
+	if err := fetchJSON(ctx, presetURLs["atlassian"], &ranges); err != nil {
+		return nil, err
+	}
+	products := queryToList(query)
+	var ips []string
+	for _, item := range ranges.Items {
+		if matchesInclude(products, item.Product...) {
+			ips = append(ips, item.CIDR)
+		}
+	}
+	return ips, nil
+}
+
+// getTorExitIPs returns the IPs of the current Tor exit nodes, which is useful for block lists.
+func getTorExitIPs(ctx context.Context) ([]string, error) {
+	return fetchLines(ctx, presetURLs["tor"])
+}
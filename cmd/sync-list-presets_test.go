@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// presetFixtures are the recorded responses in testdata/presets of each preset feed.
+var presetFixtures = map[string]string{
+	"aws":            "aws.json",
+	"gcp":            "gcp.json",
+	"fastly":         "fastly.json",
+	"pingdom-ipv4":   "pingdom-ipv4.txt",
+	"pingdom-ipv6":   "pingdom-ipv6.txt",
+	"stripe-webhook": "stripe-webhooks.json",
+	"stripe-api":     "stripe-api.json",
+	"atlassian":      "atlassian.json",
+	"tor":            "tor.txt",
+}
+
+// servePresetFixtures points the preset feeds at a server with the recorded responses.
+func servePresetFixtures(t *testing.T) {
+	t.Helper()
+	fixtureServer := httptest.NewServer(http.FileServer(http.Dir("testdata/presets")))
+	original := maps.Clone(presetURLs)
+	for preset, fixture := range presetFixtures {
+		presetURLs[preset] = fixtureServer.URL + "/" + fixture
+	}
+	t.Cleanup(func() {
+		fixtureServer.Close()
+		presetURLs = original
+	})
+}
+
+func Test_Presets(t *testing.T) {
+	servePresetFixtures(t)
+	testCases := []struct {
+		name     string
+		get      func() ([]string, error)
+		expected []string
+	}{
+		{
+			name:     "AWS",
+			get:      func() ([]string, error) { return getAWSIPs(t.Context(), url.Values{}) },
+			expected: []string{"3.2.34.0/26", "13.32.0.0/15", "52.95.245.0/24", "54.231.0.0/16", "2600:9000:3000::/36", "2600:1f18::/33"},
+		},
+		{
+			name:     "AWS service",
+			get:      func() ([]string, error) { return getAWSIPs(t.Context(), url.Values{"include": {"cloudfront"}}) },
+			expected: []string{"13.32.0.0/15", "2600:9000:3000::/36"},
+		},
+		{
+			name: "AWS service and region",
+			get: func() ([]string, error) {
+				return getAWSIPs(t.Context(), url.Values{"include": {"amazon,ec2"}, "region": {"us-east-1"}})
+			},
+			expected: []string{"52.95.245.0/24", "2600:1f18::/33"},
+		},
+		{
+			name: "Google Cloud scope",
+			get: func() ([]string, error) {
+				return getGoogleCloudIPs(t.Context(), url.Values{"include": {"us-central1"}})
+			},
+			expected: []string{"2600:1900:4000::/44", "34.16.0.0/17"},
+		},
+		{
+			name: "Azure service tag",
+			get: func() ([]string, error) {
+				return getAzureIPs(t.Context(), url.Values{"include": {"azurefrontdoor.backend"}, "file": {"testdata/presets/azure.json"}})
+			},
+			expected: []string{"13.73.248.16/29", "20.21.37.40/29", "2603:1030:21:1::1c0/123"},
+		},
+		{
+			name:     "Fastly",
+			get:      func() ([]string, error) { return getFastlyIPs(t.Context()) },
+			expected: []string{"23.235.32.0/20", "43.249.72.0/22", "103.244.50.0/24", "2a04:4e40::/32", "2a04:4e42::/32"},
+		},
+		{
+			name:     "Pingdom",
+			get:      func() ([]string, error) { return getPingdomIPs(t.Context()) },
+			expected: []string{"5.172.196.188", "13.232.220.164", "23.22.2.46", "2a02:6ea0:c305::4041", "2a02:6ea0:c700::4046"},
+		},
+		{
+			name:     "Stripe",
+			get:      func() ([]string, error) { return getStripeIPs(t.Context(), url.Values{}) },
+			expected: []string{"3.18.12.63", "3.130.192.231", "13.235.14.237"},
+		},
+		{
+			name:     "Stripe with API",
+			get:      func() ([]string, error) { return getStripeIPs(t.Context(), url.Values{"include": {"api"}}) },
+			expected: []string{"3.18.12.63", "3.130.192.231", "13.235.14.237", "13.112.224.240", "13.115.13.148"},
+		},
+		{
+			name:     "Atlassian product",
+			get:      func() ([]string, error) { return getAtlassianIPs(t.Context(), url.Values{"include": {"confluence"}}) },
+			expected: []string{"104.192.136.0/21", "2401:1d80:3000::/36"},
+		},
+		{
+			name:     "Tor",
+			get:      func() ([]string, error) { return getTorExitIPs(t.Context()) },
+			expected: []string{"171.25.193.20", "185.220.101.1", "192.42.116.16"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ips, err := tc.get()
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, ips)
+			}
+		})
+	}
+}
+
+func Test_AzurePresetErrors(t *testing.T) {
+	_, err := getAzureIPs(t.Context(), url.Values{"file": {"testdata/presets/azure.json"}})
+	assert.EqualError(t, err, "azure preset requires ?include= with the service tags to sync")
+
+	_, err = getAzureIPs(t.Context(), url.Values{"include": {"AzureMonitor"}})
+	assert.ErrorContains(t, err, "azure preset requires ?file= or ?url=")
+
+	_, err = getAzureIPs(t.Context(), url.Values{"include": {"Storage"}, "file": {"testdata/presets/azure.json"}})
+	assert.EqualError(t, err, "no Azure service tags matched Storage")
+}
+
+func Test_SyncList_NewPresets(t *testing.T) {
+	servePresetFixtures(t)
+	for _, source := range []string{"preset://aws?include=cloudfront", "preset://fastly", "preset://tor", "preset://atlassian?include=jira,bitbucket"} {
+		err := withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--mode", "diff", "--dry-run", "--source", source})
+		assert.NoError(t, err, source)
+	}
+
+	err := withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--source", "preset://oracle"})
+	assert.EqualError(t, err, "invalid preset: oracle. Valid presets are: cloudflare, uptime-robot, github, aws, gcp, azure, fastly, pingdom, stripe, atlassian, tor")
+}
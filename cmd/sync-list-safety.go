@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	maxRemovalsFlag = "max-removals"
+	minItemsFlag    = "min-items"
+
+	// maxListedRemovals is how many of the items that would be removed are listed in the error.
+	maxListedRemovals = 20
+)
+
+// removalLimit is the most items a sync can remove, either as a number of items or a percentage of the list.
+type removalLimit struct {
+	Count   int
+	Percent float64
+	IsRatio bool
+}
+
+// parseRemovalLimit parses a limit such as 100 or 25%.
+func parseRemovalLimit(value string) (removalLimit, error) {
+	invalid := fmt.Errorf("invalid %s: %s. Must be a number of items such as 100 or a percentage such as 25%%", maxRemovalsFlag, value)
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		ratio, err := strconv.ParseFloat(percent, 64)
+		if err != nil || ratio < 0 || ratio > 100 {
+			return removalLimit{}, invalid
+		}
+		return removalLimit{Percent: ratio, IsRatio: true}, nil
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return removalLimit{}, invalid
+	}
+	return removalLimit{Count: count}, nil
+}
+
+// Allows checks if removing items from a list with total items is within the limit.
+func (l removalLimit) Allows(removed, total int) bool {
+	if l.IsRatio {
+		return float64(removed)*100 <= l.Percent*float64(total)
+	}
+	return removed <= l.Count
+}
+
+func (l removalLimit) String() string {
+	if l.IsRatio {
+		return strconv.FormatFloat(l.Percent, 'f', -1, 64) + "%"
+	}
+	return strconv.Itoa(l.Count)
+}
+
+// checkListMinItems stops a sync that would leave fewer items in the list than the minimum, unless --force is set.
+func checkListMinItems(c *cli.Command, options syncListOptions, items int) error {
+	if items >= options.MinItems {
+		return nil
+	}
+	if c.Bool(forceFlag) {
+		logger.Warnf("Syncing %d items, fewer than the minimum of %d, because --%s is set", items, options.MinItems, forceFlag)
+		return nil
+	}
+	return fmt.Errorf("sources have %d items, fewer than the minimum of %d. Check the sources or use `--%s` to sync anyway", items, options.MinItems, forceFlag)
+}
+
+// checkListRemovals stops a sync that would remove more items than --max-removals allows, unless --force is set.
+// The error lists the items that would be removed, so a truncated source is easy to spot.
+func checkListRemovals(c *cli.Command, options syncListOptions, total int, toDelete []cloudflare.ListItem) error {
+	if options.MaxRemovals == "" || len(toDelete) == 0 {
+		return nil
+	}
+	// The limit was already validated with the flags or the config file.
+	limit, _ := parseRemovalLimit(options.MaxRemovals)
+	if limit.Allows(len(toDelete), total) {
+		return nil
+	}
+	if c.Bool(forceFlag) {
+		logger.Warnf("Removing %d of %d items, more than the limit of %s, because --%s is set", len(toDelete), total, limit, forceFlag)
+		return nil
+	}
+	removed := make([]string, 0, maxListedRemovals)
+	for _, item := range toDelete[:min(len(toDelete), maxListedRemovals)] {
+		removed = append(removed, listItemValue(listItemRequest(item)))
+	}
+	if len(toDelete) > maxListedRemovals {
+		removed = append(removed, fmt.Sprintf("and %d more", len(toDelete)-maxListedRemovals))
+	}
+	return fmt.Errorf("sync would remove %d of %d items, more than the limit of %s: %s. Check the sources or use `--%s` to sync anyway",
+		len(toDelete), total, limit, strings.Join(removed, ", "), forceFlag)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RemovalLimit(t *testing.T) {
+	limit, err := parseRemovalLimit("25%")
+	if assert.NoError(t, err) {
+		assert.True(t, limit.Allows(25, 100))
+		assert.False(t, limit.Allows(26, 100))
+		assert.Equal(t, "25%", limit.String())
+	}
+	limit, err = parseRemovalLimit("10")
+	if assert.NoError(t, err) {
+		assert.True(t, limit.Allows(10, 11))
+		assert.False(t, limit.Allows(11, 2000))
+	}
+	for _, input := range []string{"", "-1", "ten", "101%", "%"} {
+		_, err := parseRemovalLimit(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func Test_CheckListRemovals(t *testing.T) {
+	toDelete := make([]cloudflare.ListItem, 0, 25)
+	for range 25 {
+		toDelete = append(toDelete, cloudflare.ListItem{IP: cloudflare.StringPtr("192.0.2.1")})
+	}
+	err := checkListRemovals(buildListSyncCommand(), syncListOptions{MaxRemovals: "10%"}, 30, toDelete)
+	assert.ErrorContains(t, err, "sync would remove 25 of 30 items, more than the limit of 10%: 192.0.2.1")
+	assert.ErrorContains(t, err, "and 5 more. Check the sources or use `--force` to sync anyway")
+
+	assert.NoError(t, checkListRemovals(buildListSyncCommand(), syncListOptions{}, 30, toDelete), "Expected no limit when max-removals is not set")
+}
+
+func Test_SyncList_Thresholds(t *testing.T) {
+	fileName := "test-threshold-ips.txt"
+	err := os.WriteFile(fileName, []byte("1.2.3.4\n"), 0600)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(fileName)
+
+	// test-list has 1.2.3.4 and 198.51.100.0/24, so syncing the file removes half of the list.
+	for _, mode := range []string{replaceSyncMode, diffSyncMode} {
+		err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--mode", mode, "--max-removals", "0", "--source", "file://" + fileName})
+		assert.EqualError(t, err, "sync would remove 1 of 2 items, more than the limit of 0: 198.51.100.0/24. Check the sources or use `--force` to sync anyway", mode)
+
+		err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--mode", mode, "--max-removals", "50%", "--source", "file://" + fileName})
+		assert.NoError(t, err, mode)
+
+		err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--mode", mode, "--max-removals", "0", "--force", "--source", "file://" + fileName})
+		assert.NoError(t, err, "Expected --force to override the limit")
+	}
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--min-items", "2", "--source", "file://" + fileName})
+	assert.EqualError(t, err, "sources have 1 items, fewer than the minimum of 2. Check the sources or use `--force` to sync anyway")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--max-removals", "half", "--source", "file://" + fileName})
+	assert.EqualError(t, err, "invalid max-removals: half. Must be a number of items such as 100 or a percentage such as 25%")
+}
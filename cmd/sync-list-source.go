@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	sourceFormatFlag = "format"
+	jsonPathFlag     = "json-path"
+	csvColumnFlag    = "csv-column"
+
+	textSourceFormat = "text"
+	jsonSourceFormat = "json"
+	csvSourceFormat  = "csv"
+)
+
+var validSourceFormats = []string{textSourceFormat, jsonSourceFormat, csvSourceFormat}
+
+// sourceEntry is a single item read from a source.
+type sourceEntry struct {
+	// Line is the line number in the source, used when reporting invalid items.
+	Line  int
+	Value string
+	// Comment is the comment on the same line in the source or the label of the source. It replaces the default item comment.
+	Comment string
+	// Source is the label of the source the entry came from. It is only set when there are multiple sources.
+	Source string
+}
+
+// sourceValues converts values that did not come from a file into source entries.
+func sourceValues(values []string) []sourceEntry {
+	entries := make([]sourceEntry, 0, len(values))
+	for i, value := range values {
+		entries = append(entries, sourceEntry{Line: i + 1, Value: value})
+	}
+	return entries
+}
+
+// parseSource reads the entries of a file or URL source in the format of the options.
+func parseSource(data []byte, options syncListOptions) ([]sourceEntry, error) {
+	switch options.Format {
+	case jsonSourceFormat:
+		paths := options.JSONPaths
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("--%s is required with --%s %s", jsonPathFlag, sourceFormatFlag, jsonSourceFormat)
+		}
+		return parseJSONSource(data, paths)
+	case csvSourceFormat:
+		column := options.CSVColumn
+		if column == "" {
+			return nil, fmt.Errorf("--%s is required with --%s %s", csvColumnFlag, sourceFormatFlag, csvSourceFormat)
+		}
+		return parseCSVSource(data, column)
+	default:
+		return splitSourceLines(string(data)), nil
+	}
+}
+
+// splitSourceLines returns every non-empty line of a source.
+// Lines starting with # are skipped, and a # after whitespace starts a comment for that line.
+func splitSourceLines(data string) []sourceEntry {
+	var entries []sourceEntry
+	for i, line := range strings.Split(data, "\n") {
+		value, comment := splitLineComment(line)
+		if value != "" {
+			entries = append(entries, sourceEntry{Line: i + 1, Value: value, Comment: comment})
+		}
+	}
+	return entries
+}
+
+// splitLineComment splits a line into its value and comment.
+// A # without whitespace before it is part of the value, so URLs with a fragment are kept.
+func splitLineComment(line string) (string, string) {
+	for i, r := range line {
+		if r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		}
+	}
+	return strings.TrimSpace(line), ""
+}
+
+// parseJSONSource returns every value found at the given paths of a JSON document.
+// A path is a list of keys separated by dots. Arrays are walked automatically, and a key can end in [] to make that clear.
+func parseJSONSource(data []byte, paths []string) ([]sourceEntry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("error parsing JSON source: %w", err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("error parsing JSON source: unexpected data after the JSON document")
+	}
+	var values []string
+	for _, path := range paths {
+		var keys []string
+		for _, key := range strings.Split(path, ".") {
+			if key = strings.TrimSuffix(key, "[]"); key != "" {
+				keys = append(keys, key)
+			}
+		}
+		found := selectJSONValues(document, keys)
+		if len(found) == 0 {
+			return nil, fmt.Errorf("json path %s did not match any values", path)
+		}
+		values = append(values, found...)
+	}
+	return sourceValues(values), nil
+}
+
+// selectJSONValues walks a decoded JSON value and returns every string or number at the end of the keys.
+func selectJSONValues(value any, keys []string) []string {
+	switch v := value.(type) {
+	case []any:
+		var values []string
+		for _, item := range v {
+			values = append(values, selectJSONValues(item, keys)...)
+		}
+		return values
+	case map[string]any:
+		if len(keys) == 0 {
+			return nil
+		}
+		return selectJSONValues(v[keys[0]], keys[1:])
+	case string:
+		if len(keys) == 0 && strings.TrimSpace(v) != "" {
+			return []string{strings.TrimSpace(v)}
+		}
+	case json.Number:
+		if len(keys) == 0 {
+			return []string{v.String()}
+		}
+	}
+	return nil
+}
+
+// parseCSVSource returns the values of a column of a CSV document with a header row.
+func parseCSVSource(data []byte, column string) ([]sourceEntry, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV header: %w", err)
+	}
+	index := -1
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return nil, fmt.Errorf("column %s not found in CSV header", column)
+	}
+
+	var entries []sourceEntry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading CSV source: %w", err)
+		}
+		if index >= len(record) || strings.TrimSpace(record[index]) == "" {
+			continue
+		}
+		line, _ := reader.FieldPos(index)
+		entries = append(entries, sourceEntry{Line: line, Value: strings.TrimSpace(record[index])})
+	}
+	return entries, nil
+}
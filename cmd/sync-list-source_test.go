@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SplitSourceLines(t *testing.T) {
+	entries := splitSourceLines("# Office ranges\r\n1.2.3.4\r\n\n  2001:db8::1  # VPN\n\n198.51.100.0/24\t#\nexample.com/a,https://example.com/b#top\n")
+	assert.Equal(t, []sourceEntry{
+		{Line: 2, Value: "1.2.3.4"},
+		{Line: 4, Value: "2001:db8::1", Comment: "VPN"},
+		{Line: 6, Value: "198.51.100.0/24"},
+		{Line: 7, Value: "example.com/a,https://example.com/b#top"},
+	}, entries)
+}
+
+func Test_ParseJSONSource(t *testing.T) {
+	data := []byte(`{
+		"syncToken": "1",
+		"prefixes": [
+			{"ip_prefix": "192.0.2.0/24", "region": "us-east-1"},
+			{"ip_prefix": "198.51.100.0/24", "region": "eu-west-1"}
+		],
+		"ipv6_prefixes": [{"ipv6_prefix": "2001:db8::/32"}],
+		"asns": [13335, 209242]
+	}`)
+	entries, err := parseJSONSource(data, []string{"prefixes[].ip_prefix", "ipv6_prefixes.ipv6_prefix", "asns"})
+	if assert.NoError(t, err) {
+		values := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			values = append(values, entry.Value)
+		}
+		assert.Equal(t, []string{"192.0.2.0/24", "198.51.100.0/24", "2001:db8::/32", "13335", "209242"}, values)
+	}
+
+	_, err = parseJSONSource(data, []string{"prefixes.ip"})
+	assert.EqualError(t, err, "json path prefixes.ip did not match any values")
+
+	_, err = parseJSONSource([]byte("1.2.3.4\n"), []string{"prefixes"})
+	assert.ErrorContains(t, err, "error parsing JSON source")
+}
+
+func Test_ParseCSVSource(t *testing.T) {
+	data := []byte("name,IP Address,location\n# Comment row\nprobe-1, 192.0.2.1 ,Dallas\nprobe-2,,London\n\"probe\n3\",198.51.100.7,Tokyo\n")
+	entries, err := parseCSVSource(data, "ip address")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []sourceEntry{{Line: 3, Value: "192.0.2.1"}, {Line: 6, Value: "198.51.100.7"}}, entries)
+	}
+
+	_, err = parseCSVSource(data, "ip")
+	assert.EqualError(t, err, "column ip not found in CSV header")
+}
+
+func Test_SyncList_Formats(t *testing.T) {
+	fileName := "test-ips.json"
+	err := os.WriteFile(fileName, []byte(`{"prefixes":[{"ip_prefix":"1.2.3.4/32"},{"ip_prefix":"203.0.113.0/24"}]}`), 0600)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(fileName)
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--format", "json", "--json-path", "prefixes.ip_prefix", "--mode", "diff", "--source", "file://" + fileName})
+	assert.NoError(t, err, "Expected no error when syncing a json source")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--format", "json", "--source", "file://" + fileName})
+	assert.EqualError(t, err, "error parsing source: --json-path is required with --format json")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--format", "csv", "--source", "file://" + fileName})
+	assert.EqualError(t, err, "error parsing source: --csv-column is required with --format csv")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--format", "xml", "--source", "file://" + fileName})
+	assert.EqualError(t, err, "invalid format: xml. Valid formats are: text, json, csv")
+}
+
+func Test_SplitSourceLabel(t *testing.T) {
+	testCases := map[string][2]string{
+		"Pingdom=preset://pingdom":          {"Pingdom", "preset://pingdom"},
+		"Office VPN = file://vpn.txt":       {"Office VPN", "file://vpn.txt"},
+		"preset://cloudflare?include=china": {"", "preset://cloudflare?include=china"},
+		"https://example.com/ips?a=b":       {"", "https://example.com/ips?a=b"},
+		"file://ips.txt":                    {"", "file://ips.txt"},
+	}
+	for input, expected := range testCases {
+		label, source := splitSourceLabel(input)
+		assert.Equal(t, expected, [2]string{label, source}, input)
+	}
+	assert.Equal(t, "pingdom", defaultSourceLabel("preset://pingdom"))
+	assert.Equal(t, "example.com", defaultSourceLabel("https://example.com/ips.txt"))
+	assert.Equal(t, "ips.txt", defaultSourceLabel("file://ips.txt"))
+}
+
+func Test_MergeItemComments(t *testing.T) {
+	assert.Equal(t, "Pingdom", mergeItemComments("", "Pingdom"))
+	assert.Equal(t, "Pingdom", mergeItemComments("Pingdom", ""))
+	assert.Equal(t, "Pingdom, Uptime Robot", mergeItemComments("Pingdom", "Uptime Robot"))
+	assert.Equal(t, "Pingdom, Uptime Robot", mergeItemComments("Pingdom, Uptime Robot", "Uptime Robot"))
+}
+
+func Test_SyncList_MultipleSources(t *testing.T) {
+	files := map[string]string{
+		"test-vendor-a.txt": "1.2.3.4\n192.0.2.0/24 # Probes\n",
+		"test-vendor-b.txt": "1.2.3.4/32\n2001:db8::1\n",
+		"test-vendor-c.txt": "203.0.113.1\nnot-an-ip\n",
+	}
+	for fileName, contents := range files {
+		if err := os.WriteFile(fileName, []byte(contents), 0600); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		defer os.Remove(fileName)
+	}
+
+	err := withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--mode", "diff",
+		"--source", "Vendor A=file://test-vendor-a.txt", "--source", "file://test-vendor-b.txt"})
+	assert.NoError(t, err, "Expected no error when syncing multiple sources")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "--list-name", "test-list", "--dry-run",
+		"--source", "Vendor A=file://test-vendor-a.txt", "file://test-vendor-c.txt"})
+	assert.EqualError(t, err, `error parsing ip list items: test-vendor-c.txt line 2: invalid IP or CIDR: "not-an-ip"`)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	listStatusSubCommand = "status"
+	listTimeoutFlag      = "timeout"
+
+	defaultListTimeout = 10 * time.Minute
+)
+
+// SyncListStatus shows the status of a list bulk operation, such as one started with --no-wait.
+// With --wait, it waits for the operation to complete instead.
+func SyncListStatus(ctx context.Context, c *cli.Command) error {
+	if accountRC == nil {
+		return fmt.Errorf("account ID must be set for this command")
+	}
+	operationID := c.Args().First()
+	if operationID == "" {
+		return errors.New("operation ID must be provided as an argument")
+	}
+	if c.Bool("wait") {
+		logger.Infof("Waiting for operation %s", operationID)
+		if err := PollListBulkOperation(ctx, accountRC, operationID, c.Duration(listTimeoutFlag)); err != nil {
+			return fmt.Errorf("error waiting for operation %s: %w", operationID, err)
+		}
+		fmt.Printf("Operation %s completed\n", operationID)
+		return nil
+	}
+
+	operation, err := APIClient.GetListBulkOperation(ctx, accountRC, operationID)
+	if err != nil {
+		return fmt.Errorf("error getting operation %s: %w", operationID, err)
+	}
+	switch operation.Status {
+	case "failed":
+		return fmt.Errorf("operation %s failed: %s", operationID, operation.Error)
+	case "completed":
+		if operation.Completed != nil {
+			fmt.Printf("Operation %s completed at %s\n", operationID, operation.Completed.Format(time.RFC3339))
+			return nil
+		}
+	}
+	fmt.Printf("Operation %s is %s\n", operationID, operation.Status)
+	return nil
+}
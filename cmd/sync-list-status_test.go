@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SyncListStatus(t *testing.T) {
+	err := withApp(t, []string{"cloudflare-utils", "sync-list", "status", "4da8780eeb215e6cb7f48dd981c4ea02"})
+	assert.NoError(t, err, "Expected no error for a completed operation")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "status", "7c1e7d1f2b7a4e0fa6c54e2a3f9b8d10"})
+	assert.NoError(t, err, "Expected no error for a running operation")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "status", "0b6f2d8e9a3c4b5d8e7f6a5b4c3d2e1f"})
+	assert.EqualError(t, err, "operation 0b6f2d8e9a3c4b5d8e7f6a5b4c3d2e1f failed: This list is at the maximum number of items")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "status", "--wait", "4da8780eeb215e6cb7f48dd981c4ea02"})
+	assert.NoError(t, err, "Expected no error waiting for a completed operation")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "status", "--wait", "--timeout", "1s", "7c1e7d1f2b7a4e0fa6c54e2a3f9b8d10"})
+	assert.EqualError(t, err, "error waiting for operation 7c1e7d1f2b7a4e0fa6c54e2a3f9b8d10: bulk operation did not finish before timeout")
+
+	err = withApp(t, []string{"cloudflare-utils", "sync-list", "status"})
+	assert.EqualError(t, err, "operation ID must be provided as an argument")
+}
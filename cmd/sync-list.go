@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"strings"
 	"time"
 
@@ -21,15 +22,74 @@ const (
 	ipBothFlag = "both"
 )
 
+// syncListOptions are the settings of a single list to sync, from the flags or a config file.
+type syncListOptions struct {
+	ListID      string
+	ListName    string
+	Description string
+	Kind        string
+	Sources     []string
+	IPVersion   string
+	Comment     string
+	NoComment   bool
+	Mode        string
+	Aggregate   bool
+	SkipInvalid bool
+	Format      string
+	JSONPaths   []string
+	CSVColumn   string
+	MaxRemovals string
+	MinItems    int
+}
+
+// syncListOptionsFromFlags returns the options set by the flags of the sync-list command.
+func syncListOptionsFromFlags(c *cli.Command) syncListOptions {
+	return syncListOptions{
+		ListID:      c.String("list-id"),
+		ListName:    c.String("list-name"),
+		Description: c.String("list-description"),
+		Kind:        c.String(listKindFlag),
+		Sources:     append(c.StringSlice("source"), c.Args().Slice()...),
+		IPVersion:   c.String("ip-version"),
+		Comment:     c.String("comment"),
+		NoComment:   c.Bool("no-comment"),
+		Mode:        c.String(syncModeFlag),
+		Aggregate:   c.Bool(aggregateFlag),
+		SkipInvalid: c.Bool(skipInvalidFlag),
+		Format:      c.String(sourceFormatFlag),
+		JSONPaths:   c.StringSlice(jsonPathFlag),
+		CSVColumn:   c.String(csvColumnFlag),
+		MaxRemovals: c.String(maxRemovalsFlag),
+		MinItems:    c.Int(minItemsFlag),
+	}
+}
+
 var (
-	validPresets = []string{"cloudflare", "uptime-robot", "github"}
+	validPresets = []string{"cloudflare", "uptime-robot", "github", "aws", "gcp", "azure", "fastly", "pingdom", "stripe", "atlassian", "tor"}
 )
 
 func buildListSyncCommand() *cli.Command {
 	return &cli.Command{
 		Name:   "sync-list",
-		Usage:  "Syncs a list of IPs with a Cloudflare List. This currently replaces all items in a list\nAPI Token Requirements: Account Filter Lists:Edit",
+		Usage:  "Syncs IPs, hostnames, ASNs or redirects with a Cloudflare List. Either replaces all items in the list or only adds and removes the items that changed\nAPI Token Requirements: Account Filter Lists:Edit",
 		Action: SyncList,
+		Commands: []*cli.Command{
+			{
+				Name:      listStatusSubCommand,
+				Usage:     "Show the status of a list operation started with --no-wait",
+				ArgsUsage: "<operation-id>",
+				Action:    SyncListStatus,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "wait",
+						Usage: "Wait for the operation to complete or --timeout to pass",
+						Value: false,
+					},
+				},
+			},
+		},
+		// Sources and JSON paths can contain commas, so slice flags are only split by repeating the flag.
+		DisableSliceFlagSeparator: true,
 		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:  "list-name",
@@ -40,34 +100,83 @@ func buildListSyncCommand() *cli.Command {
 				Usage: "ID of the list to sync with. If both list-name and list-id are provided, list-id will be used.",
 			},
 			&cli.StringFlag{
+				Name:  "list-description",
+				Usage: "Description of the list. Only used when the list is created.",
+				Value: "Created by cloudflare-utils",
+			},
+			&cli.StringFlag{
+				Name:  syncListConfigFlag,
+				Usage: "YAML file that declares multiple lists to sync in one run. The list and source flags are then used as the defaults of every list.",
+			},
+			&cli.StringFlag{
+				Name: listKindFlag,
+				Usage: "Kind of items in the list. Can be ip, hostname, asn or redirect. Sources contain one item per line. " +
+					"Redirects are CSV rows of source_url,target_url and optionally status_code, preserve_query_string, include_subdomains, subpath_matching and preserve_path_suffix.",
+				Value: cloudflare.ListTypeIP,
+				Action: func(_ context.Context, _ *cli.Command, s string) error {
+					if !common.StringSearch(s, validListKinds) {
+						return fmt.Errorf("invalid kind: %s. Valid kinds are: %s", s, strings.Join(validListKinds, ", "))
+					}
+					return nil
+				},
+			},
+			&cli.StringSliceFlag{
 				Name: "source",
-				Usage: "Source of the IPs to sync. Can be a URL, file path, or preset. URL and file path must start with http(s):// or file:// respectively.\n" +
-					"Presets starts with preset://. Currently, support presets are: \n" +
+				Usage: "Source of the items to sync. Can be a URL, file path, or preset. URL and file path must start with http(s):// or file:// respectively.\n" +
+					"Can be set multiple times to merge sources into one list. Prefix a source with label= to use the label as the comment of its items.\n" +
+					"Presets starts with preset:// and can only be used with ip lists. Currently, support presets are: \n" +
 					"  - cloudflare. You can also do ?include=china to include China DC IP addresses\n" +
 					"  - uptime-robot\n" +
 					"  - github\n" +
+					"  - aws. You can also do ?include=<services> and ?region=<regions> to filter the ranges\n" +
+					"  - gcp. You can also do ?include=<scopes> to filter the ranges\n" +
+					"  - azure. Requires ?include=<service tags> and ?file= or ?url= with the service tags file\n" +
+					"  - fastly\n" +
+					"  - pingdom\n" +
+					"  - stripe. Webhook IPs. You can also do ?include=api to include the API IPs\n" +
+					"  - atlassian. You can also do ?include=<products> to filter the ranges\n" +
+					"  - tor. Tor exit nodes\n" +
 					"For more information on formats, see: https://cloudflare-utils.cyberjake.xyz/lists/sync-list/",
-				Action: func(_ context.Context, _ *cli.Command, s string) error {
-					sourceURL, err := url.Parse(s)
-					if err != nil {
-						return fmt.Errorf("precheck error parsing source URL: %w", err)
-					}
-					switch sourceURL.Scheme {
-					case "http", "https", "file":
-						return nil
-					case "preset":
-						if !common.StringSearch(sourceURL.Host, validPresets) {
-							return fmt.Errorf("invalid preset: %s. Valid presets are: %s", sourceURL.Host, strings.Join(validPresets, ", "))
+				Action: func(_ context.Context, _ *cli.Command, sources []string) error {
+					for _, source := range sources {
+						_, source = splitSourceLabel(source)
+						if _, err := validateSource(source); err != nil {
+							return err
 						}
-					default:
-						return fmt.Errorf("invalid source scheme: %s", sourceURL.Scheme)
 					}
 					return nil
 				},
 			},
+			&cli.StringFlag{
+				Name:  sourceFormatFlag,
+				Usage: fmt.Sprintf("Format of file and URL sources. Can be %s, %s or %s.", textSourceFormat, jsonSourceFormat, csvSourceFormat),
+				Value: textSourceFormat,
+				Action: func(_ context.Context, _ *cli.Command, s string) error {
+					if !common.StringSearch(s, validSourceFormats) {
+						return fmt.Errorf("invalid format: %s. Valid formats are: %s", s, strings.Join(validSourceFormats, ", "))
+					}
+					return nil
+				},
+			},
+			&cli.StringSliceFlag{
+				Name:  jsonPathFlag,
+				Usage: "Path to the values in a json source, such as prefixes.ip_prefix. Arrays are walked automatically. Can be set multiple times.",
+			},
+			&cli.StringFlag{
+				Name:  csvColumnFlag,
+				Usage: "Name of the column in the header row of a csv source that has the values.",
+			},
+			&cli.BoolFlag{
+				Name:  aggregateFlag,
+				Usage: "Collapse overlapping and adjacent CIDRs into the fewest CIDRs that cover the same IPs. Only used with ip lists.",
+			},
+			&cli.BoolFlag{
+				Name:  skipInvalidFlag,
+				Usage: "Skip invalid items in the source with a warning instead of failing.",
+			},
 			&cli.StringFlag{
 				Name:  "ip-version",
-				Usage: fmt.Sprintf("IP version to sync. Can be either %s, %s, or %s. Default is %s.", ipv4Flag, ipv6Flag, ipBothFlag, ipBothFlag),
+				Usage: fmt.Sprintf("IP version to sync. Only used with ip lists. Can be either %s, %s, or %s. Default is %s.", ipv4Flag, ipv6Flag, ipBothFlag, ipBothFlag),
 				Value: "both",
 				Action: func(_ context.Context, _ *cli.Command, s string) error {
 					validVersions := []string{ipv4Flag, ipv6Flag, ipBothFlag}
@@ -78,11 +187,57 @@ func buildListSyncCommand() *cli.Command {
 					return nil
 				},
 			},
+			&cli.StringFlag{
+				Name: syncModeFlag,
+				Usage: fmt.Sprintf("How to sync the list. %s replaces every item in the list. %s only adds missing items and removes items no longer in the source, keeping the comments of existing items.",
+					replaceSyncMode, diffSyncMode),
+				Value: replaceSyncMode,
+				Action: func(_ context.Context, _ *cli.Command, s string) error {
+					if !common.StringSearch(s, []string{replaceSyncMode, diffSyncMode}) {
+						return fmt.Errorf("invalid mode: %s. Valid modes are: %s, %s", s, replaceSyncMode, diffSyncMode)
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  maxRemovalsFlag,
+				Usage: "Most items a sync can remove from the list, either a number of items such as 100 or a percentage of the list such as 25%. Protects the list from a truncated source.",
+				Action: func(_ context.Context, _ *cli.Command, s string) error {
+					_, err := parseRemovalLimit(s)
+					return err
+				},
+			},
+			&cli.IntFlag{
+				Name:  minItemsFlag,
+				Usage: "Fewest items the sources must have for the list to be synced.",
+				Action: func(_ context.Context, _ *cli.Command, i int) error {
+					if i < 0 {
+						return fmt.Errorf("%s cannot be negative", minItemsFlag)
+					}
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:  forceFlag,
+				Usage: fmt.Sprintf("Sync even if the list would be shrunk past --%s or --%s.", maxRemovalsFlag, minItemsFlag),
+				Value: false,
+			},
 			&cli.BoolFlag{
 				Name:  "no-wait",
-				Usage: "If set, the command will not wait for the list sync operation to complete. This means that the command will exit immediately after starting the operation. You can check the status of the operation later using the operation ID.",
+				Usage: "If set, the command will not wait for the list sync operation to complete. This means that the command will exit immediately after starting the operation. You can check the status of the operation later with `sync-list status <operation-id>`.",
 				Value: false,
 			},
+			&cli.DurationFlag{
+				Name:  listTimeoutFlag,
+				Usage: "How long to wait for a list operation to complete.",
+				Value: defaultListTimeout,
+				Action: func(_ context.Context, _ *cli.Command, d time.Duration) error {
+					if d <= 0 {
+						return fmt.Errorf("%s must be greater than 0", listTimeoutFlag)
+					}
+					return nil
+				},
+			},
 			&cli.BoolFlag{
 				Name:  dryRunFlag,
 				Usage: "Don't actually sync anything. Just print what would be synced.",
@@ -108,148 +263,270 @@ func buildListSyncCommand() *cli.Command {
 }
 
 func SyncList(ctx context.Context, c *cli.Command) error {
-	listName := c.String("list-name")
-	listID := c.String("list-id")
-	if listName == "" && listID == "" {
+	if c.IsSet(syncListConfigFlag) {
+		return syncListsFromConfig(ctx, c)
+	}
+	options := syncListOptionsFromFlags(c)
+	if options.ListName == "" && options.ListID == "" {
 		return fmt.Errorf("either --list-id or --list-name must be provided")
 	}
-	listSource := c.String("source")
-	if listSource == "" {
-		listSource = c.Args().First()
-		if listSource == "" {
-			return fmt.Errorf("source must be provided as an argument or with --source")
+	if len(options.Sources) == 0 {
+		return fmt.Errorf("source must be provided as an argument or with --source")
+	}
+	_, err := syncList(ctx, c, options)
+	return err
+}
+
+// syncList reads the sources of a list and syncs the items to it. It returns the number of items synced.
+func syncList(ctx context.Context, c *cli.Command, options syncListOptions) (int, error) {
+	var entries []sourceEntry
+	for _, source := range options.Sources {
+		label, listSource := splitSourceLabel(source)
+		sourceEntries, err := readSource(ctx, c, options, listSource)
+		if err != nil {
+			return 0, err
+		}
+		if len(options.Sources) > 1 {
+			if label == "" {
+				label = defaultSourceLabel(listSource)
+			}
+			logger.Infof("Read %d items from %s", len(sourceEntries), label)
 		}
+		for i := range sourceEntries {
+			if len(options.Sources) > 1 {
+				sourceEntries[i].Source = label
+			}
+			if sourceEntries[i].Comment == "" {
+				sourceEntries[i].Comment = label
+			}
+		}
+		entries = append(entries, sourceEntries...)
 	}
-	sourceURL, err := url.Parse(listSource)
+
+	if len(entries) == 0 {
+		return 0, fmt.Errorf("no items found to sync")
+	}
+
+	listItems, err := parseListItems(entries, options)
 	if err != nil {
-		return fmt.Errorf("error parsing source URL: %w", err)
+		return 0, fmt.Errorf("error parsing %s list items: %w", options.Kind, err)
 	}
-	var ips []string
+	if err := checkListMinItems(c, options, len(listItems)); err != nil {
+		return 0, err
+	}
+
+	listID := options.ListID
+	if listID == "" {
+		listID, err = getCloudflareList(ctx, c, options)
+		if err != nil {
+			return 0, fmt.Errorf("error getting Cloudflare list: %w", err)
+		}
+	}
+	if listID == "" {
+		return 0, fmt.Errorf("list ID is empty after attempting to fetch or create the list")
+	}
+
+	logger.Infof("Syncing %d items to list ID %s", len(listItems), listID)
+	if options.Mode == diffSyncMode {
+		return len(listItems), syncListDiff(ctx, c, options, listID, listItems)
+	}
+	// Replacing the list only needs the current items to check how many would be removed.
+	if options.MaxRemovals != "" && listID != dryRunListID {
+		current, err := APIClient.ListListItems(ctx, accountRC, cloudflare.ListListItemsParams{ID: listID})
+		if err != nil {
+			return 0, fmt.Errorf("error getting current list items: %w", err)
+		}
+		if err := checkListRemovals(c, options, len(current), diffListItems(current, listItems).ToDelete); err != nil {
+			return 0, err
+		}
+	}
+	if c.Bool(dryRunFlag) {
+		fmt.Printf("Dry Run: Would sync %d items to list ID %s\n", len(listItems), listID)
+		return len(listItems), nil
+	}
+	syncStart := time.Now()
+	opID, err := APIClient.ReplaceListItemsAsync(ctx, accountRC, cloudflare.ListReplaceItemsParams{ID: listID, Items: listItems})
+	if err != nil {
+		return 0, fmt.Errorf("error replacing list items: %w", err)
+	}
+	if c.Bool("no-wait") {
+		fmt.Printf("Started async operation to replace list items. Operation ID: %s\n", opID.Result.OperationID)
+		return len(listItems), nil
+	}
+	logger.Infof("Started async operation to replace list items. Operation ID: %s", opID.Result.OperationID)
+	err = PollListBulkOperation(ctx, accountRC, opID.Result.OperationID, c.Duration(listTimeoutFlag))
+	if err != nil {
+		return 0, fmt.Errorf("error polling list bulk operation: %w", err)
+	}
+	logger.Debugf("List sync operation completed in %s", time.Since(syncStart).String())
+	fmt.Printf("Successfully synced %d items to list ID %s\n", len(listItems), listID)
+	return len(listItems), nil
+}
+
+// readSource returns the entries of a single source.
+func readSource(ctx context.Context, c *cli.Command, options syncListOptions, listSource string) ([]sourceEntry, error) {
+	sourceURL, err := validateSource(listSource)
+	if err != nil {
+		return nil, err
+	}
+	if sourceURL.Scheme == "preset" && options.Kind != cloudflare.ListTypeIP {
+		return nil, fmt.Errorf("presets can only be used with %s lists", cloudflare.ListTypeIP)
+	}
+	var entries []sourceEntry
 	switch sourceURL.Scheme {
 	case "preset":
+		var ips []string
 		switch sourceURL.Host {
 		case "cloudflare":
-			ips, err = getCloudflareIPs(c, sourceURL.Query())
+			ips, err = getCloudflareIPs(options.IPVersion, sourceURL.Query())
 			if err != nil {
-				return fmt.Errorf("error getting Cloudflare IPs: %w", err)
+				return nil, fmt.Errorf("error getting Cloudflare IPs: %w", err)
 			}
 		case "uptime-robot":
 			ips, err = getUptimeRobotIPs(ctx)
 			if err != nil {
-				return fmt.Errorf("error getting Uptime Robot IPs: %w", err)
+				return nil, fmt.Errorf("error getting Uptime Robot IPs: %w", err)
 			}
 		case "github":
 			ips, err = getGitHubIPs(ctx, c, sourceURL.Query())
 			if err != nil {
-				return fmt.Errorf("error getting GitHub IPs: %w", err)
+				return nil, fmt.Errorf("error getting GitHub IPs: %w", err)
+			}
+		case "aws":
+			ips, err = getAWSIPs(ctx, sourceURL.Query())
+			if err != nil {
+				return nil, fmt.Errorf("error getting AWS IPs: %w", err)
+			}
+		case "gcp":
+			ips, err = getGoogleCloudIPs(ctx, sourceURL.Query())
+			if err != nil {
+				return nil, fmt.Errorf("error getting Google Cloud IPs: %w", err)
+			}
+		case "azure":
+			ips, err = getAzureIPs(ctx, sourceURL.Query())
+			if err != nil {
+				return nil, fmt.Errorf("error getting Azure IPs: %w", err)
+			}
+		case "fastly":
+			ips, err = getFastlyIPs(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("error getting Fastly IPs: %w", err)
+			}
+		case "pingdom":
+			ips, err = getPingdomIPs(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("error getting Pingdom IPs: %w", err)
+			}
+		case "stripe":
+			ips, err = getStripeIPs(ctx, sourceURL.Query())
+			if err != nil {
+				return nil, fmt.Errorf("error getting Stripe IPs: %w", err)
+			}
+		case "atlassian":
+			ips, err = getAtlassianIPs(ctx, sourceURL.Query())
+			if err != nil {
+				return nil, fmt.Errorf("error getting Atlassian IPs: %w", err)
+			}
+		case "tor":
+			ips, err = getTorExitIPs(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("error getting Tor exit IPs: %w", err)
 			}
 		default:
-			return fmt.Errorf("invalid preset: %s", sourceURL.Host)
+			return nil, fmt.Errorf("invalid preset: %s", sourceURL.Host)
 		}
+		entries = sourceValues(ips)
 	case "http", "https":
-		ips, err = getIPsFromURL(ctx, listSource)
+		data, err := fetchURL(ctx, listSource)
+		if err != nil {
+			return nil, fmt.Errorf("error getting IPs from URL: %w", err)
+		}
+		entries, err = parseSource(data, options)
 		if err != nil {
-			return fmt.Errorf("error getting IPs from URL: %w", err)
+			return nil, fmt.Errorf("error parsing source: %w", err)
 		}
 
 	case "file":
-		filePath := sourceURL.Host
+		filePath := sourceFilePath(sourceURL)
 		if !common.FileExists(filePath) {
-			return fmt.Errorf("file does not exist: %s", filePath)
+			return nil, fmt.Errorf("file does not exist: %s", filePath)
 		}
 		data, err := os.ReadFile(filePath)
 		if err != nil {
-			return fmt.Errorf("error reading file: %w", err)
+			return nil, fmt.Errorf("error reading file: %w", err)
 		}
-		lines := strings.Split(string(data), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line != "" {
-				ips = append(ips, line)
-			}
+		entries, err = parseSource(data, options)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing source: %w", err)
 		}
 	}
+	return entries, nil
+}
 
-	if len(ips) == 0 {
-		return fmt.Errorf("no IPs found to sync")
-	}
+// splitSourceLabel splits a source written as label=source into the label and the source.
+func splitSourceLabel(source string) (string, string) {
+	label, rest, found := strings.Cut(source, "=")
+	// The = in a query such as preset://cloudflare?include=china is not a label.
+	if !found || strings.Contains(label, "://") || !strings.Contains(rest, "://") {
+		return "", source
+	}
+	return strings.TrimSpace(label), strings.TrimSpace(rest)
+}
 
-	if listID == "" {
-		listID, err = getCloudflareList(ctx, c)
-		if err != nil {
-			return fmt.Errorf("error getting Cloudflare list: %w", err)
-		}
+// defaultSourceLabel is the label of a source that was not given one: the preset name, host or file name.
+func defaultSourceLabel(listSource string) string {
+	sourceURL, err := url.Parse(listSource)
+	if err != nil {
+		return listSource
 	}
-	if listID == "" {
-		return fmt.Errorf("list ID is empty after attempting to fetch or create the list")
+	if sourceURL.Scheme == "file" {
+		return path.Base(sourceFilePath(sourceURL))
 	}
+	return sourceURL.Host
+}
 
-	listItems := getFilteredIPs(ips, c)
+// sourceFilePath returns the path of a file source.
+// The first part of a relative path such as file://backups/list.txt is parsed as the host, so it is joined with the path.
+// An absolute path such as file:///backups/list.txt has no host.
+func sourceFilePath(sourceURL *url.URL) string {
+	return sourceURL.Host + sourceURL.Path
+}
 
-	logger.Infof("Syncing %d IPs to list ID %s", len(listItems), listID)
-	if c.Bool(dryRunFlag) {
-		fmt.Printf("Dry Run: Would sync %d IPs to list ID %s\n", len(listItems), listID)
-		return nil
-	}
-	syncStart := time.Now()
-	opID, err := APIClient.ReplaceListItemsAsync(ctx, accountRC, cloudflare.ListReplaceItemsParams{ID: listID, Items: listItems})
+// validateSource checks that a source has a supported scheme and preset.
+func validateSource(listSource string) (*url.URL, error) {
+	sourceURL, err := url.Parse(listSource)
 	if err != nil {
-		return fmt.Errorf("error replacing list items: %w", err)
-	}
-	if c.Bool("no-wait") {
-		fmt.Printf("Started async operation to replace list items. Operation ID: %s\n", opID.Result.OperationID)
-		return nil
+		return nil, fmt.Errorf("precheck error parsing source URL: %w", err)
 	}
-	logger.Infof("Started async operation to replace list items. Operation ID: %s", opID.Result.OperationID)
-	err = PollListBulkOperation(ctx, accountRC, opID.Result.OperationID)
-	if err != nil {
-		return fmt.Errorf("error polling list bulk operation: %w", err)
+	switch sourceURL.Scheme {
+	case "http", "https", "file":
+	case "preset":
+		if !common.StringSearch(sourceURL.Host, validPresets) {
+			return nil, fmt.Errorf("invalid preset: %s. Valid presets are: %s", sourceURL.Host, strings.Join(validPresets, ", "))
+		}
+	default:
+		return nil, fmt.Errorf("invalid source scheme: %s", sourceURL.Scheme)
 	}
-	logger.Debugf("List sync operation completed in %s", time.Since(syncStart).String())
-	fmt.Printf("Successfully synced %d IPs to list ID %s\n", len(listItems), listID)
-	return nil
+	return sourceURL, nil
 }
 
-func getFilteredIPs(ips []string, c *cli.Command) []cloudflare.ListItemCreateRequest {
-	listItems := make([]cloudflare.ListItemCreateRequest, 0, len(ips))
-	ipVersion := c.String("ip-version")
-
+// listItemComment returns the comment added to every synced list item.
+func listItemComment(options syncListOptions) string {
 	comment := "Added by cloudflare-utils sync-list on " + startTime.Format(time.RFC822Z)
-	customComment := c.String("comment")
+	customComment := options.Comment
 	if customComment != "" {
 		comment = customComment
 	}
 	// Override comment if no-comment is set
-	if c.Bool("no-comment") {
+	if options.NoComment {
 		comment = ""
 	}
-	for _, ip := range ips {
-		if ip == "" {
-			logger.Warn("Skipping empty IP")
-			continue
-		}
-		if ipVersion == ipv4Flag && strings.Contains(ip, ".") && !strings.Contains(ip, ":") {
-			listItems = append(listItems, cloudflare.ListItemCreateRequest{
-				IP:      cloudflare.StringPtr(ip),
-				Comment: comment,
-			})
-		} else if ipVersion == ipv6Flag && strings.Contains(ip, ":") && !strings.Contains(ip, ".") {
-			listItems = append(listItems, cloudflare.ListItemCreateRequest{
-				IP:      cloudflare.StringPtr(ip),
-				Comment: comment,
-			})
-		} else if ipVersion == ipBothFlag {
-			listItems = append(listItems, cloudflare.ListItemCreateRequest{
-				IP:      cloudflare.StringPtr(ip),
-				Comment: comment,
-			})
-		}
-	}
-	return listItems
+	return comment
 }
 
-func getCloudflareList(ctx context.Context, c *cli.Command) (string, error) {
-	listName := c.String("list-name")
+func getCloudflareList(ctx context.Context, c *cli.Command, options syncListOptions) (string, error) {
+	listName := options.ListName
+	kind := options.Kind
 	// Fetch list by Name
 	logger.Infof("Fetching list by name: %s", listName)
 	if accountRC == nil {
@@ -262,13 +539,16 @@ func getCloudflareList(ctx context.Context, c *cli.Command) (string, error) {
 	for _, list := range lists {
 		if list.Name == listName {
 			logger.Infof("Found list with name %s and ID %s", list.Name, list.ID)
+			if list.Kind != kind {
+				return "", fmt.Errorf("list %s has kind %s, not %s", list.Name, list.Kind, kind)
+			}
 			return list.ID, nil
 		}
 	}
 	// Create list if not found
 	if c.Bool(dryRunFlag) {
 		fmt.Printf("Dry Run: Would have created list with name %s\n", listName)
-		return "dry-run-list-id", nil
+		return dryRunListID, nil
 	}
 	if listName == "" {
 		return "", fmt.Errorf("could not find list and list name is empty, cannot create list")
@@ -276,8 +556,8 @@ func getCloudflareList(ctx context.Context, c *cli.Command) (string, error) {
 	logger.Infof("List with name %s not found, creating it", listName)
 	newList, err := APIClient.CreateList(ctx, accountRC, cloudflare.ListCreateParams{
 		Name:        listName,
-		Description: "Created by cloudflare-utils",
-		Kind:        "ip",
+		Description: options.Description,
+		Kind:        kind,
 	})
 	if err != nil {
 		return "", fmt.Errorf("error creating list: %w", err)
@@ -286,14 +566,13 @@ func getCloudflareList(ctx context.Context, c *cli.Command) (string, error) {
 	return newList.ID, nil
 }
 
-func getCloudflareIPs(c *cli.Command, query url.Values) ([]string, error) {
+func getCloudflareIPs(ipVersion string, query url.Values) ([]string, error) {
 	var ips []string
 	ranges, err := cloudflare.IPs()
 	if err != nil {
 		return nil, fmt.Errorf("error fetching Cloudflare IPs: %w", err)
 	}
 	includeChina := queryToList(query)["china"]
-	ipVersion := c.String("ip-version")
 	if ipVersion == ipv4Flag || ipVersion == ipBothFlag {
 		ips = append(ips, ranges.IPv4CIDRs...)
 	}
@@ -313,7 +592,7 @@ func getCloudflareIPs(c *cli.Command, query url.Values) ([]string, error) {
 }
 
 func getUptimeRobotIPs(ctx context.Context) ([]string, error) {
-	return getIPsFromURL(ctx, "https://cdn.uptimerobot.com/api/IPv4andIPv6.txt")
+	return fetchLines(ctx, presetURLs["uptime-robot"])
 }
 
 func getGitHubIPs(ctx context.Context, c *cli.Command, query url.Values) ([]string, error) {
@@ -371,8 +650,8 @@ func getGitHubIPs(ctx context.Context, c *cli.Command, query url.Values) ([]stri
 	return deduped, nil
 }
 
-func getIPsFromURL(ctx context.Context, url string) ([]string, error) {
-	var ips []string
+// fetchURL returns the body of a source URL.
+func fetchURL(ctx context.Context, url string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
@@ -389,23 +668,20 @@ func getIPsFromURL(ctx context.Context, url string) ([]string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error reading IPs from URL: %w", err)
 	}
-	lines := strings.Split(string(body), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			ips = append(ips, line)
-		}
-	}
-	return ips, nil
+	return body, nil
 }
 
 func queryToList(query url.Values) map[string]bool {
 	// Parse include query parameter
 	// Example: ?include=china,foo,bar
 	// Result: map[string]bool{"china": true, "foo": true, "bar": true}
+	return queryKeyToList(query, "include")
+}
 
+// queryKeyToList parses a comma separated query parameter the same way as queryToList.
+func queryKeyToList(query url.Values, key string) map[string]bool {
 	includes := make(map[string]bool)
-	include := query.Get("include")
+	include := query.Get(key)
 	if include != "" {
 		for _, inc := range strings.Split(include, ",") {
 			includes[strings.TrimSpace(strings.ToLower(inc))] = true
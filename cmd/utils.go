@@ -6,11 +6,11 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/go-github/v90/github"
-	"github.com/sourcegraph/conc/pool"
 
 	"github.com/cloudflare/cloudflare-go"
 	"github.com/sirupsen/logrus"
@@ -126,57 +126,68 @@ func DeploymentsPaginate(params PagesDeploymentPaginationOptions) ([]cloudflare.
 	return deployments, nil
 }
 
+// ListAllPagesProjects lists every Pages project in the account.
+// ListPagesProjects does not paginate on its own, so this requests pages until all projects are returned.
+func ListAllPagesProjects(ctx context.Context) ([]cloudflare.PagesProject, error) {
+	if accountRC == nil {
+		return nil, errors.New("`account-id` is required to list pages projects")
+	}
+	var projects []cloudflare.PagesProject
+	params := cloudflare.ListPagesProjectsParams{PaginationOptions: cloudflare.PaginationOptions{Page: 1}}
+	for {
+		res, resultInfo, err := APIClient.ListPagesProjects(ctx, accountRC, params)
+		if err != nil {
+			return projects, fmt.Errorf("api error listing pages projects: %w", err)
+		}
+		projects = append(projects, res...)
+		if len(res) == 0 || resultInfo.Page >= resultInfo.TotalPages {
+			break
+		}
+		params.Page = resultInfo.Page + 1
+	}
+	logger.Debugf("Got %d pages projects", len(projects))
+	return projects, nil
+}
+
 // RapidDNSDelete is a helper function to delete DNS records quickly.
 // Uses a pool of goroutines to delete records in parallel.
-func RapidDNSDelete(rc *cloudflare.ResourceContainer, dnsRecords []cloudflare.DNSRecord) map[string]error {
-	p := pool.NewWithResults[bool]()
-	results := make(map[string]error)
-	p.WithMaxGoroutines(maxGoRoutines)
-	for _, dnsRecord := range dnsRecords {
-		p.Go(func() bool {
-			err := APIClient.DeleteDNSRecord(context.Background(), rc, dnsRecord.ID)
+func RapidDNSDelete(ctx context.Context, rc *cloudflare.ResourceContainer, dnsRecords []cloudflare.DNSRecord) bulkReport {
+	return runBulk(ctx, dnsRecords,
+		func(record cloudflare.DNSRecord) string { return record.ID },
+		func(ctx context.Context, record cloudflare.DNSRecord) error {
+			err := APIClient.DeleteDNSRecord(ctx, rc, record.ID)
 			if err != nil {
-				logger.WithError(err).Warningf("Error deleting DNS record: %s\n", dnsRecord.ID)
-				results[dnsRecord.ID] = err
-				return false
+				logger.WithError(err).Warningf("Error deleting DNS record: %s\n", record.ID)
 			}
-			return true
+			return err
 		},
-		)
-	}
-	p.Wait()
-	return results
+		defaultBulkOptions,
+	)
 }
 
 // RapidPagesDeploymentDelete is a helper function to delete Pages deployments quickly.
 // Uses a pool of goroutines to delete deployments in parallel.
-func RapidPagesDeploymentDelete(options pruneDeploymentOptions) map[string]error {
-	p := pool.NewWithResults[bool]()
-	goRoutines := maxGoRoutines
+func RapidPagesDeploymentDelete(ctx context.Context, options pruneDeploymentOptions) bulkReport {
+	bulk := defaultBulkOptions
 	if options.c.Bool(lotsOfDeploymentsFlag) {
-		goRoutines = 5
+		bulk.MaxGoroutines = 5
 	}
-	results := make(map[string]error)
-	p.WithMaxGoroutines(goRoutines)
 	forceDelete := options.c.Bool(forceFlag)
-	for _, deployment := range options.SelectedDeployments {
-		p.Go(func() bool {
-			err := APIClient.DeletePagesDeployment(context.Background(), accountRC, cloudflare.DeletePagesDeploymentParams{
+	return runBulk(ctx, options.SelectedDeployments,
+		func(deployment cloudflare.PagesProjectDeployment) string { return deployment.ID },
+		func(ctx context.Context, deployment cloudflare.PagesProjectDeployment) error {
+			err := APIClient.DeletePagesDeployment(ctx, accountRC, cloudflare.DeletePagesDeploymentParams{
 				ProjectName:  options.ProjectName,
 				DeploymentID: deployment.ID,
 				Force:        forceDelete,
 			})
 			if err != nil {
 				logger.WithError(err).Warningf("Error deleting deployment: %s", deployment.ID)
-				results[deployment.ID] = err
-				return false
 			}
-			return true
+			return err
 		},
-		)
-	}
-	p.Wait()
-	return results
+		bulk,
+	)
 }
 
 type APIPermissionName string
@@ -266,9 +277,22 @@ const (
 	errOperationStillRunning     = "bulk operation did not finish before timeout"
 )
 
-func PollListBulkOperation(ctx context.Context, rc *cloudflare.ResourceContainer, ID string) error {
-	for i := range uint8(16) {
-		sleepDuration := 1 << (i / 2) * time.Second
+// maxListPollInterval is the longest PollListBulkOperation waits between checks of an operation.
+const maxListPollInterval = 2 * time.Minute
+
+// PollListBulkOperation waits for a list bulk operation to complete, checking it with an exponential backoff until the timeout.
+func PollListBulkOperation(ctx context.Context, rc *cloudflare.ResourceContainer, ID string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	backoff := time.Second
+	for i := 0; ; i++ {
+		sleepDuration := min(backoff, time.Until(deadline))
+		if sleepDuration <= 0 {
+			return errors.New(errOperationStillRunning)
+		}
+		// The backoff doubles every two checks.
+		if i%2 == 1 {
+			backoff = min(backoff*2, maxListPollInterval)
+		}
 		select {
 		case <-time.After(sleepDuration):
 		case <-ctx.Done():
@@ -291,8 +315,51 @@ func PollListBulkOperation(ctx context.Context, rc *cloudflare.ResourceContainer
 			return fmt.Errorf("%s: %s", errOperationUnexpectedStatus, bulkResult.Status)
 		}
 	}
+}
+
+// relativeDurationUnits are the units supported by ParseRelativeDuration in addition to the ones of time.ParseDuration.
+var relativeDurationUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"M": 30 * 24 * time.Hour,
+	"y": 365 * 24 * time.Hour,
+}
 
-	return errors.New(errOperationStillRunning)
+// ParseRelativeDuration parses a duration like time.ParseDuration but also supports days (d), weeks (w), months (M) and years (y).
+// Months are 30 days and years are 365 days. Units can be combined, for example `1w3d` or `2d12h`.
+func ParseRelativeDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, errors.New("empty duration")
+	}
+	var total time.Duration
+	rest := value
+	for rest != "" {
+		end := strings.IndexFunc(rest, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if end <= 0 {
+			return 0, fmt.Errorf("invalid duration: %s", value)
+		}
+		unitEnd := strings.IndexFunc(rest[end:], func(r rune) bool { return (r >= '0' && r <= '9') || r == '.' })
+		if unitEnd == -1 {
+			unitEnd = len(rest) - end
+		}
+		number, unit := rest[:end], rest[end:end+unitEnd]
+		rest = rest[end+unitEnd:]
+		if multiplier, ok := relativeDurationUnits[unit]; ok {
+			amount, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", value)
+			}
+			total += time.Duration(amount * float64(multiplier))
+			continue
+		}
+		parsed, err := time.ParseDuration(number + unit)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", value)
+		}
+		total += parsed
+	}
+	return total, nil
 }
 
 func buildGithubClient(githubToken string) (*github.Client, error) {
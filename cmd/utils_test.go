@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseRelativeDuration(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{value: "30d", expected: 30 * 24 * time.Hour},
+		{value: "2w", expected: 14 * 24 * time.Hour},
+		{value: "1w3d", expected: 10 * 24 * time.Hour},
+		{value: "2d12h", expected: 60 * time.Hour},
+		{value: "1M", expected: 30 * 24 * time.Hour},
+		{value: "1y", expected: 365 * 24 * time.Hour},
+		{value: "90m", expected: 90 * time.Minute},
+		{value: "1.5h", expected: 90 * time.Minute},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			duration, err := ParseRelativeDuration(tc.value)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, duration)
+		})
+	}
+	for _, value := range []string{"", "d", "10", "10x", "-5d"} {
+		_, err := ParseRelativeDuration(value)
+		assert.Error(t, err, "Expected an error parsing %q", value)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/Cyb3r-Jak3/cloudflare-utils/cmd"
@@ -25,8 +27,14 @@ func main() {
 			Logger:    logger,
 			StartTime: startTime,
 		})
-	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), cmd.VersionContextKey, version))
+	// Ctrl-C cancels the context so bulk operations stop starting new requests.
+	ctx, cancel := signal.NotifyContext(context.WithValue(context.Background(), cmd.VersionContextKey, version), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
+	// Stop catching signals after the first one, so a second Ctrl-C kills the process even while it waits on stdin.
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
 	err := app.Run(ctx, os.Args)
 	logger.Debugf("Running took: %v", time.Since(startTime))
 	if err != nil {